@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Actions of the REST API.
+const (
+	apiStart  = "start"
+	apiPause  = "pause"
+	apiReset  = "reset"
+	apiSkip   = "skip"
+	apiStatus = "status"
+)
+
+// apiState is the timer's state as reported by the REST API.
+type apiState struct {
+	Phase     string `json:"phase"`
+	Remaining int    `json:"remaining_seconds"`
+	Sessions  int    `json:"sessions"`
+}
+
+// apiMsg hands an API request to the model, which answers on reply.
+type apiMsg struct {
+	action string
+	reply  chan apiState
+}
+
+// serveAPI serves the REST API on addr until the program ends. Actions are
+// POSTed to /start, /pause, /reset and /skip; GET /status only reads the
+// state. Every request answers with the state after it.
+//
+// Requests have to bring the token, if one is set, which it has to be to
+// serve beyond this machine. Browsers are turned away, so web pages can't
+// drive the timer.
+func serveAPI(addr, token string, p *tea.Program) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	local := isLoopback(host)
+	if !local && token == "" {
+		return nil, fmt.Errorf("serving the API on %s needs an api_token in the config", addr)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	handle := func(pattern, action string) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if status, err := checkAPIRequest(r, local, token); err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			reply := make(chan apiState, 1)
+			p.Send(apiMsg{action: action, reply: reply})
+
+			select {
+			case state := <-reply:
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(state)
+			case <-time.After(time.Second * 5):
+				http.Error(w, "the timer didn't answer", http.StatusServiceUnavailable)
+			case <-r.Context().Done():
+			}
+		})
+	}
+	handle("POST /start", apiStart)
+	handle("POST /pause", apiPause)
+	handle("POST /reset", apiReset)
+	handle("POST /skip", apiSkip)
+	handle("GET /status", apiStatus)
+
+	go http.Serve(ln, mux)
+	return ln, nil
+}
+
+// checkAPIRequest rejects requests from browsers and, on the loopback
+// interface, requests for other hosts, which is how DNS rebinding gets
+// pages in. Without the token, if there is one, they're unauthorized.
+func checkAPIRequest(r *http.Request, local bool, token string) (int, error) {
+	if r.Header.Get("Origin") != "" {
+		return http.StatusForbidden, errors.New("cross-origin requests aren't allowed")
+	}
+	if local {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopback(host) {
+			return http.StatusForbidden, fmt.Errorf("host %q isn't allowed", r.Host)
+		}
+	}
+	if token != "" {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return http.StatusUnauthorized, errors.New("missing or wrong bearer token")
+		}
+	}
+	return 0, nil
+}
+
+// isLoopback reports whether host names the loopback interface. An empty
+// host is every interface.
+func isLoopback(host string) bool {
+	host = strings.Trim(host, "[]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// updateAPI runs an API action and reports the resulting state. Nothing
+// is asked in the UI on the way: no intention, no pause reason.
+func (m model) updateAPI(msg apiMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	now := time.Now()
+	switch msg.action {
+	case apiStart:
+		if m.timer.Timedout() {
+			return m.updateAPI(apiMsg{action: apiSkip, reply: msg.reply})
+		}
+		if !m.timer.Running() {
+			var next tea.Model
+			next, cmd = m.toggle("api")
+			m = next.(model)
+			// Report the new state right away rather than after the
+			// timer's start message.
+			m.pomo.Start(now)
+		}
+	case apiPause:
+		if m.timer.Running() {
+			var next tea.Model
+			next, cmd = m.toggle("api")
+			m = next.(model)
+			m.askingReason = false
+			m.pomo.Pause(now)
+		}
+	case apiReset:
+		var next tea.Model
+		next, cmd = m.reset()
+		m = next.(model)
+	case apiSkip:
+		var next tea.Model
+		switch {
+		case m.intervalMode():
+			next, cmd = m.undoableSession("skip").startSegment(m.segment + 1)
+		case m.pomo.OnBreak():
+			next, cmd = m.undoableSession("skip to work").endSession(false).beginWork()
+		default:
+			next, cmd = m.undoableSession("skip to break").startBreak()
+		}
+		m = next.(model)
+	}
+
+	msg.reply <- apiState{
+		Phase:     m.phase(),
+		Remaining: int((m.pomo.Remaining(time.Now()) + time.Second - 1) / time.Second),
+		Sessions:  m.completedToday(),
+	}
+	return m, cmd
+}
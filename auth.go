@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"charm/test/internal/keyring"
+	"charm/test/internal/oauth"
+)
+
+// oauthClient returns the OAuth client of a service that can be logged in
+// to with "pomodoro auth".
+func oauthClient(cfg config, service string) (oauth.Config, error) {
+	switch service {
+	case "google":
+		if cfg.Google.ClientID == "" {
+			return oauth.Config{}, errors.New("config: google.client_id is needed to log in to Google")
+		}
+		return oauth.Config{
+			DeviceURL:    "https://oauth2.googleapis.com/device/code",
+			TokenURL:     "https://oauth2.googleapis.com/token",
+			RevokeURL:    "https://oauth2.googleapis.com/revoke",
+			ClientID:     cfg.Google.ClientID,
+			ClientSecret: cfg.Google.ClientSecret,
+			Scopes:       []string{"https://www.googleapis.com/auth/calendar.readonly"},
+		}, nil
+	case "gitlab":
+		if cfg.GitLab.ClientID == "" {
+			return oauth.Config{}, errors.New("config: gitlab.client_id is needed to log in to GitLab")
+		}
+		base := strings.TrimSuffix(cfg.GitLab.URL, "/")
+		return oauth.Config{
+			DeviceURL: base + "/oauth/authorize_device",
+			TokenURL:  base + "/oauth/token",
+			RevokeURL: base + "/oauth/revoke",
+			ClientID:  cfg.GitLab.ClientID,
+			Scopes:    []string{"api"},
+		}, nil
+	default:
+		return oauth.Config{}, fmt.Errorf("can't log in to %q, only to google and gitlab", service)
+	}
+}
+
+// loginName names the keyring secret holding a service's login.
+func loginName(service string) string {
+	return "oauth." + service
+}
+
+func loadLogin(ring *keyring.Keyring, service string) (oauth.Token, error) {
+	var tok oauth.Token
+	data, err := ring.Get(loginName(service))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return tok, fmt.Errorf("not logged in to %s, run: pomodoro auth %s", service, service)
+	}
+	if err != nil {
+		return tok, err
+	}
+	return tok, json.Unmarshal([]byte(data), &tok)
+}
+
+func saveLogin(ring *keyring.Keyring, service string, tok oauth.Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	return ring.Set(loginName(service), string(data))
+}
+
+// oauthSource hands out the access tokens of the login to a service,
+// keeping refreshed ones in the keyring.
+func oauthSource(cfg config, service string) *oauth.Source {
+	client, clientErr := oauthClient(cfg, service)
+	ring, ringErr := openKeyring()
+	load := func() (oauth.Token, error) {
+		if err := errors.Join(clientErr, ringErr); err != nil {
+			return oauth.Token{}, err
+		}
+		return loadLogin(ring, service)
+	}
+	save := func(tok oauth.Token) error {
+		return saveLogin(ring, service, tok)
+	}
+	return oauth.NewSource(client, load, save)
+}
+
+// runAuth implements the "auth" subcommand, which logs in to a service in
+// the browser, or logs out with --revoke.
+func runAuth(args []string) error {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	revoke := fs.Bool("revoke", false, "revoke the login and forget it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: pomodoro auth [--revoke] google|gitlab")
+	}
+	service := fs.Arg(0)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := oauthClient(cfg, service)
+	if err != nil {
+		return err
+	}
+	ring, err := openKeyring()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *revoke {
+		tok, err := loadLogin(ring, service)
+		if err != nil {
+			return err
+		}
+		if err := client.Revoke(ctx, tok); err != nil {
+			return err
+		}
+		if err := ring.Delete(loginName(service)); err != nil {
+			return err
+		}
+		fmt.Printf("Logged out of %s.\n", service)
+		return nil
+	}
+
+	device, err := client.Start(ctx)
+	if err != nil {
+		return err
+	}
+	if device.CompleteURL != "" {
+		fmt.Printf("Open %s\nand check that it shows the code %s.\n", device.CompleteURL, device.Code)
+	} else {
+		fmt.Printf("Open %s\nand enter the code %s.\n", device.URL, device.Code)
+	}
+	fmt.Println("Waiting for the login to be approved...")
+
+	tok, err := client.Wait(ctx, device)
+	if err != nil {
+		return err
+	}
+	if err := saveLogin(ring, service, tok); err != nil {
+		return err
+	}
+	fmt.Printf("Logged in to %s. The login is kept in the %s.\n", service, ring.Backend())
+	return nil
+}
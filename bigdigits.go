@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+)
+
+// bigGlyphs are five-row renderings of the characters in a countdown.
+var bigGlyphs = map[rune][5]string{
+	'0': {"█████", "█   █", "█   █", "█   █", "█████"},
+	'1': {"  █  ", " ██  ", "  █  ", "  █  ", " ███ "},
+	'2': {"█████", "    █", "█████", "█    ", "█████"},
+	'3': {"█████", "    █", " ████", "    █", "█████"},
+	'4': {"█   █", "█   █", "█████", "    █", "    █"},
+	'5': {"█████", "█    ", "█████", "    █", "█████"},
+	'6': {"█████", "█    ", "█████", "█   █", "█████"},
+	'7': {"█████", "    █", "   █ ", "  █  ", "  █  "},
+	'8': {"█████", "█   █", "█████", "█   █", "█████"},
+	'9': {"█████", "█   █", "█████", "    █", "█████"},
+	':': {"  ", "██", "  ", "██", "  "},
+	' ': {"  ", "  ", "  ", "  ", "  "},
+}
+
+// bigText renders s in large block digits. Characters without a glyph
+// are skipped. Without Unicode support the blocks are drawn with '#'.
+func bigText(s string, unicode bool) string {
+	var rows [5]strings.Builder
+	for _, r := range s {
+		glyph, ok := bigGlyphs[r]
+		if !ok {
+			continue
+		}
+		for i, row := range glyph {
+			if rows[i].Len() > 0 {
+				rows[i].WriteString(" ")
+			}
+			rows[i].WriteString(row)
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for i := range rows {
+		lines[i] = rows[i].String()
+		if !unicode {
+			lines[i] = strings.ReplaceAll(lines[i], "█", "#")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/clipboard"
+	"charm/test/internal/history"
+	"charm/test/internal/termcap"
+)
+
+// statusLine describes the timer in one line, like "Work, 12:34 left".
+func (m model) statusLine() string {
+	s := fmt.Sprintf("%s, %s left", m.phaseLabel(), formatDuration(m.timer.Timeout, durationFormat))
+	if m.task != "" {
+		s += " on " + m.task
+	}
+	if m.intention != "" && !m.pomo.OnBreak() {
+		s += ": " + m.intention
+	}
+	return s
+}
+
+// todaySummary summarizes today's work sessions from the history.
+func todaySummary(ctx context.Context, store *history.Store) (string, error) {
+	now := time.Now()
+	sessions, err := store.Between(ctx, startOfDay(now), now.Add(time.Second))
+	if err != nil {
+		return "", err
+	}
+
+	s := summarize(sessions)
+	return fmt.Sprintf("Today: %d pomodoros, %s of focus time", s.completed, formatDuration(s.focus, formatCompact)), nil
+}
+
+// clipboardWriter is where OSC 52 sequences go, if the terminal takes them.
+func (m model) clipboardWriter() io.Writer {
+	if !m.caps.OSC {
+		return nil
+	}
+	return os.Stdout
+}
+
+// copyStatus copies the status line to the clipboard.
+func (m model) copyStatus() (tea.Model, tea.Cmd) {
+	text, w := m.statusLine(), m.clipboardWriter()
+	copyCmd := m.jobs.Run("clipboard", time.Second*5, func(ctx context.Context) error {
+		return clipboard.Copy(ctx, w, text)
+	})
+
+	m, toastCmd := m.showToast("Copied status")
+	return m, tea.Batch(copyCmd, toastCmd)
+}
+
+// copySummary copies today's summary to the clipboard.
+func (m model) copySummary() (tea.Model, tea.Cmd) {
+	if m.history == nil {
+		return m.showToast("No history to summarize")
+	}
+
+	store, w := m.history, m.clipboardWriter()
+	copyCmd := m.jobs.Run("clipboard", time.Second*5, func(ctx context.Context) error {
+		text, err := todaySummary(ctx, store)
+		if err != nil {
+			return err
+		}
+		return clipboard.Copy(ctx, w, text)
+	})
+
+	m, toastCmd := m.showToast("Copied today's summary")
+	return m, tea.Batch(copyCmd, toastCmd)
+}
+
+// copySummaryNow copies today's summary for the --copy flag, without
+// starting the timer.
+func copySummaryNow() error {
+	store, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	text, err := todaySummary(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer
+	if termcap.Detect().OSC {
+		w = os.Stdout
+	}
+	if err := clipboard.Copy(ctx, w, text); err != nil {
+		return err
+	}
+	fmt.Println(text)
+	return nil
+}
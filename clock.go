@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// clockJumpThreshold is how far the wall clock may drift from the
+// monotonic clock between two checks before it counts as a jump.
+const clockJumpThreshold = time.Second * 2
+
+// checkClock detects changes of the system clock, such as NTP corrections
+// or manual changes, by comparing how much wall-clock and monotonic time
+// passed since the last check.
+//
+// The countdown runs to a deadline on the wall clock. A forward jump can't
+// be told apart from a suspend, so it counts as time that passed. A
+// backward jump can only be a clock change, so the deadline and the
+// session's start move back with it instead of adding time to the phase.
+func (m model) checkClock() model {
+	now := time.Now()
+	if !m.clockCheck.IsZero() {
+		jump := now.Round(0).Sub(m.clockCheck.Round(0)) - now.Sub(m.clockCheck)
+		switch {
+		case jump < -clockJumpThreshold:
+			log.Printf("system clock went back by %s, moving the deadline along", -jump.Round(time.Second))
+			m.pomo.Shift(jump)
+			if !m.sessionStart.IsZero() {
+				m.sessionStart = m.sessionStart.Add(jump)
+			}
+		case jump > clockJumpThreshold:
+			log.Printf("system clock went forward by %s, counting it as elapsed", jump.Round(time.Second))
+		}
+	}
+
+	m.clockCheck = now
+	return m
+}
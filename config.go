@@ -0,0 +1,363 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// config holds the user's settings from config.toml.
+type config struct {
+	Work              duration      `toml:"work"`
+	Break             duration      `toml:"break"`
+	LongBreak         duration      `toml:"long_break"`
+	LongBreakInterval int           `toml:"long_break_interval"`
+	AutoStart         bool          `toml:"auto_start"`
+	DailyGoal         int           `toml:"daily_goal"`
+	TickInterval      duration      `toml:"tick_interval"`
+	AskIntention      bool          `toml:"ask_intention"`
+	Intervals         string        `toml:"intervals"`
+	Deadline          string        `toml:"deadline"`
+	TimeFormat        timeFormat    `toml:"time_format"`
+	AskPauseReason    bool          `toml:"ask_pause_reason"`
+	PauseReasons      []pauseReason `toml:"pause_reasons"`
+	AskReview         bool          `toml:"ask_review"`
+	ProgressStyle     string        `toml:"progress_style"`
+	Colors            colors        `toml:"colors"`
+	Keys              keys          `toml:"keys"`
+	OTLPEndpoint      string        `toml:"otlp_endpoint"`
+	APIToken          string        `toml:"api_token"`
+	Notifications     bool          `toml:"notifications"`
+	NotifyBackend     string        `toml:"notify_backend"`
+	NotifyCommand     string        `toml:"notify_command"`
+	TerminalAlerts    bool          `toml:"terminal_alerts"`
+	TerminalTitle     bool          `toml:"terminal_title"`
+	Sound             bool          `toml:"sound"`
+	SoundFile         string        `toml:"sound_file"`
+	Ledger            string        `toml:"ledger"`
+	TasksFile         string        `toml:"tasks_file"`
+	Trello            trelloConfig  `toml:"trello"`
+	Linear            linearConfig  `toml:"linear"`
+	GitLab            gitlabConfig  `toml:"gitlab"`
+	Google            googleConfig  `toml:"google"`
+	TaskCacheTTL      duration      `toml:"task_cache_ttl"`
+}
+
+// duration is a time.Duration written like "25m" in the config file.
+type duration struct {
+	time.Duration
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// trelloConfig points the task list at a Trello list.
+type trelloConfig struct {
+	Key   string `toml:"key"`
+	Token string `toml:"token"`
+	// List is the ID of the list whose cards are the tasks.
+	List string `toml:"list"`
+	// DoneList names the list on the same board done cards move to.
+	DoneList string `toml:"done_list"`
+}
+
+// linearConfig lists the Linear issues assigned to the user as tasks.
+type linearConfig struct {
+	APIKey string `toml:"api_key"`
+}
+
+// gitlabConfig lists GitLab issues as tasks.
+type gitlabConfig struct {
+	URL   string `toml:"url"`
+	Token string `toml:"token"`
+	// Project is the ID or path of the project whose issues are listed.
+	// Without one, the issues assigned to the token's owner are.
+	Project string `toml:"project"`
+	// ClientID is the ID of an OAuth application to log in with through
+	// "pomodoro auth gitlab" instead of using a token.
+	ClientID string `toml:"client_id"`
+}
+
+// googleConfig is the OAuth client "pomodoro auth google" logs in with,
+// one of the "TVs and Limited Input devices" type.
+type googleConfig struct {
+	ClientID     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+}
+
+type pauseReason struct {
+	Key   string `toml:"key"`
+	Label string `toml:"label"`
+}
+
+type colors struct {
+	ProgressStart string `toml:"progress_start"`
+	ProgressEnd   string `toml:"progress_end"`
+	Border        string `toml:"border"`
+	Text          string `toml:"text"`
+}
+
+// keys overrides the keys of a binding when set.
+type keys struct {
+	Start       []string `toml:"start"`
+	Stop        []string `toml:"stop"`
+	Reset       []string `toml:"reset"`
+	Quit        []string `toml:"quit"`
+	Break       []string `toml:"break"`
+	Work        []string `toml:"work"`
+	Pair        []string `toml:"pair"`
+	GuestToggle []string `toml:"guest_toggle"`
+	GuestReady  []string `toml:"guest_ready"`
+	Park        []string `toml:"park"`
+	Tasks       []string `toml:"tasks"`
+	Name        []string `toml:"name"`
+	ToggleHelp  []string `toml:"toggle_help"`
+	BigClock    []string `toml:"big_clock"`
+	Debug       []string `toml:"debug_snapshot"`
+	Undo        []string `toml:"undo"`
+	CopyStatus  []string `toml:"copy_status"`
+	CopyToday   []string `toml:"copy_today"`
+}
+
+func defaultConfig() config {
+	return config{
+		Work:              duration{time.Minute * 25},
+		Break:             duration{time.Minute * 5},
+		LongBreak:         duration{time.Minute * 15},
+		LongBreakInterval: 4,
+		TickInterval:      duration{time.Millisecond * 250},
+		TimeFormat:        formatClock,
+		AskPauseReason:    true,
+		PauseReasons: []pauseReason{
+			{Key: "p", Label: "phone"},
+			{Key: "c", Label: "colleague"},
+			{Key: "b", Label: "bio"},
+			{Key: "o", Label: "other"},
+		},
+		AskReview:      true,
+		AskIntention:   true,
+		Notifications:  true,
+		TerminalAlerts: true,
+		TerminalTitle:  true,
+		Sound:          true,
+		Trello:         trelloConfig{DoneList: "Done"},
+		GitLab:         gitlabConfig{URL: "https://gitlab.com"},
+		TaskCacheTTL:   duration{time.Minute * 10},
+		ProgressStyle:  progressBar,
+		Colors: colors{
+			ProgressStart: "#5A56E0",
+			ProgressEnd:   "#EE6FF8",
+			Border:        "#7D56F4",
+			Text:          "63",
+		},
+	}
+}
+
+// configDir is where config.toml lives: $XDG_CONFIG_HOME/pomodoro or
+// ~/.config/pomodoro, and %AppData%\pomodoro on Windows.
+func configDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserConfigDir()
+		return filepath.Join(dir, "pomodoro"), err
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pomodoro"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	return filepath.Join(home, ".config", "pomodoro"), err
+}
+
+// dataDir is where history and state live: $XDG_DATA_HOME/pomodoro or
+// ~/.local/share/pomodoro, and %LocalAppData%\pomodoro on Windows.
+func dataDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "pomodoro"), nil
+		}
+		dir, err := os.UserConfigDir()
+		return filepath.Join(dir, "pomodoro"), err
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "pomodoro"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "pomodoro"), err
+}
+
+// loadConfig reads config.toml on top of the defaults. A missing file
+// just yields the defaults.
+func loadConfig() (config, error) {
+	cfg := defaultConfig()
+
+	dir, err := configDir()
+	if err != nil {
+		return cfg, err
+	}
+	path := filepath.Join(dir, "config.toml")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if cfg, err = decodeConfig(string(data)); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.resolveSecrets(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+// decodeConfig reads the settings in data on top of the defaults, without
+// resolving secrets or validating them.
+func decodeConfig(data string) (config, error) {
+	cfg := defaultConfig()
+
+	md, err := toml.Decode(data, &cfg)
+	if err != nil {
+		return cfg, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("unknown setting %q", undecoded[0].String())
+	}
+	return cfg, nil
+}
+
+func (c config) validate() error {
+	if c.Work.Duration <= 0 || c.Break.Duration <= 0 || c.LongBreak.Duration <= 0 {
+		return errors.New("config: work and break durations must be positive")
+	}
+	if c.LongBreakInterval < 1 {
+		return errors.New("config: long_break_interval must be at least 1")
+	}
+	if c.TickInterval.Duration < time.Millisecond*50 || c.TickInterval.Duration > time.Second {
+		return errors.New("config: tick_interval must be between 50ms and 1s")
+	}
+	if c.DailyGoal < 0 {
+		return errors.New("config: daily_goal can't be negative")
+	}
+
+	if c.Trello.List != "" && (c.Trello.Key == "" || c.Trello.Token == "") {
+		return errors.New("config: trello needs a key and a token")
+	}
+	if c.TaskCacheTTL.Duration < 0 {
+		return errors.New("config: task_cache_ttl can't be negative")
+	}
+	sources := 0
+	for _, set := range []bool{c.TasksFile != "", c.Trello.List != "", c.Linear.APIKey != "", c.GitLab.Token != "" || c.GitLab.ClientID != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return errors.New("config: use only one of tasks_file, trello, linear and gitlab")
+	}
+
+	if c.ProgressStyle != progressBar && c.ProgressStyle != progressRing {
+		return fmt.Errorf("config: progress_style must be %q or %q", progressBar, progressRing)
+	}
+
+	switch c.TimeFormat {
+	case formatClock, formatLongClock, formatCompact, formatLocalized:
+	default:
+		return fmt.Errorf("config: unknown time_format %q", c.TimeFormat)
+	}
+
+	switch strings.ToLower(filepath.Ext(c.SoundFile)) {
+	case "", ".wav", ".mp3":
+	default:
+		return errors.New("config: sound_file must be a WAV or MP3 file")
+	}
+
+	for _, r := range c.PauseReasons {
+		if r.Key == "" || r.Label == "" {
+			return errors.New("config: pause reasons need a key and a label")
+		}
+	}
+
+	return nil
+}
+
+// override replaces the configured durations with the ones given on the
+// command line. Zero leaves a duration as configured.
+func (c *config) override(work, brk time.Duration) error {
+	if work < 0 || brk < 0 {
+		return errors.New("--work and --break must be positive")
+	}
+	if work > time.Hour*24 || brk > time.Hour*24 {
+		return errors.New("--work and --break must be shorter than a day")
+	}
+
+	if work > 0 {
+		c.Work.Duration = work
+	}
+	if brk > 0 {
+		c.Break.Duration = brk
+	}
+	return nil
+}
+
+// apply overrides the bindings for every action with keys configured.
+func (k keys) apply(km *keymap) {
+	for _, o := range []struct {
+		keys    []string
+		binding *key.Binding
+	}{
+		{k.Start, &km.start},
+		{k.Stop, &km.stop},
+		{k.Reset, &km.reset},
+		{k.Quit, &km.quit},
+		{k.Break, &km.pauseTimer},
+		{k.Work, &km.workTimer},
+		{k.Pair, &km.pair},
+		{k.GuestToggle, &km.guestToggle},
+		{k.GuestReady, &km.guestReady},
+		{k.Park, &km.park},
+		{k.Tasks, &km.tasks},
+		{k.Name, &km.name},
+		{k.ToggleHelp, &km.toggleHelp},
+		{k.BigClock, &km.bigClock},
+		{k.Debug, &km.debug},
+		{k.Undo, &km.undo},
+		{k.CopyStatus, &km.copyStatus},
+		{k.CopyToday, &km.copyToday},
+	} {
+		if len(o.keys) == 0 {
+			continue
+		}
+		o.binding.SetKeys(o.keys...)
+		o.binding.SetHelp(strings.Join(o.keys, "/"), o.binding.Help().Desc)
+	}
+}
+
+// reasonBindings turns the configured pause reasons into key bindings.
+func (c config) reasonBindings() []key.Binding {
+	bindings := make([]key.Binding, len(c.PauseReasons))
+	for i, r := range c.PauseReasons {
+		bindings[i] = key.NewBinding(key.WithKeys(r.Key), key.WithHelp(r.Key, r.Label))
+	}
+	return bindings
+}
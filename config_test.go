@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func FuzzDecodeConfig(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		`work = "25m"`,
+		"work = \"50m\"\nbreak = \"10m\"\nlong_break_interval = 3\ndaily_goal = 8",
+		"intervals = \"4x(25w 5b) 30b\"\ndeadline = \"17:30\"",
+		"time_format = \"compact\"\nprogress_style = \"ring\"",
+		"[[pause_reasons]]\nkey = \"m\"\nlabel = \"meeting\"",
+		"[keys]\nstart = [\"s\", \"enter\"]",
+		"[trello]\nlist = \"abc\"",
+		`work = "-1h"`,
+		`tick_interval = "1ns"`,
+		`unknown = 1`,
+		`work = 25`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data string) {
+		cfg, err := decodeConfig(data)
+		if err != nil || cfg.validate() != nil {
+			return
+		}
+		// A valid config must also get past what the model parses out of it.
+		if cfg.Intervals != "" {
+			parseSequence(cfg.Intervals)
+		}
+		if cfg.Deadline != "" {
+			parseDeadline(time.Now(), cfg.Deadline)
+		}
+	})
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"charm/test/internal/events"
+	"charm/test/internal/history"
+	"charm/test/internal/notify"
+	"charm/test/internal/otlp"
+	"charm/test/internal/pomodoro"
+)
+
+// Commands the daemon takes over its socket.
+const (
+	daemonStart  = "start"
+	daemonPause  = "pause"
+	daemonStatus = "status"
+	daemonSkip   = "skip"
+)
+
+// daemonRequest is a client's command, a single JSON line.
+type daemonRequest struct {
+	Command string `json:"command"`
+}
+
+// daemonReply is the daemon's answer, a single JSON line with the timer's
+// state after the command.
+type daemonReply struct {
+	State     string        `json:"state"`
+	Kind      string        `json:"kind"`
+	Phase     string        `json:"phase"`
+	Remaining time.Duration `json:"remaining"`
+	Length    time.Duration `json:"length"`
+	Completed int           `json:"completed"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// socketPath is where the daemon listens: $XDG_RUNTIME_DIR/pomodoro.sock,
+// or next to the history when there's no runtime directory.
+func socketPath() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pomodoro.sock"), nil
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pomodoro.sock"), nil
+}
+
+// daemon holds the timer for clients, so it survives closing the terminal.
+type daemon struct {
+	cfg   config
+	queue *events.Queue
+
+	mu           sync.Mutex
+	pomo         pomodoro.Machine
+	sessionStart time.Time
+	expiry       *time.Timer
+	// shown is the phase last announced to the subscribers.
+	shown string
+}
+
+// runDaemon implements the "daemon" subcommand.
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: pomodoro daemon")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	d := &daemon{
+		cfg: cfg,
+		pomo: pomodoro.New(pomodoro.Config{
+			Work:              cfg.Work.Duration,
+			ShortBreak:        cfg.Break.Duration,
+			LongBreak:         cfg.LongBreak.Duration,
+			LongBreakInterval: cfg.LongBreakInterval,
+		}),
+	}
+
+	path, err := socketPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// A socket nobody answers on is left over from a daemon that died.
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	var notifiers []notifier
+	if cfg.Notifications {
+		n, err := notify.ByName(cfg.NotifyBackend, cfg.NotifyCommand)
+		if err != nil {
+			log.Printf("desktop notifications disabled: %v", err)
+		} else if dryRun {
+			notifiers = append(notifiers, dryNotifier{n})
+		} else {
+			notifiers = append(notifiers, n)
+		}
+	}
+	if cfg.OTLPEndpoint != "" {
+		telemetry = otlp.New(cfg.OTLPEndpoint, "pomodoro")
+	}
+
+	store, err := openHistory()
+	if err != nil {
+		log.Printf("history disabled: %v", err)
+	} else {
+		defer store.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		if err := reconcileJournal(ctx, store); err != nil {
+			log.Printf("reconciling the session journal: %v", err)
+		}
+		now := time.Now()
+		today, err := store.Between(ctx, startOfDay(now), now.Add(time.Second))
+		if err != nil {
+			log.Printf("loading today's sessions: %v", err)
+		}
+		cancel()
+		d.pomo.SetCompleted(summarize(today).completed, now)
+	}
+	d.queue = events.NewQueue(newEventBus(cfg, store, newTaskProvider(cfg, store), newDispatcher(notifiers...)), eventTimeout)
+	d.phaseChanged(time.Now())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		ln.Close()
+	}()
+
+	log.Printf("listening on %s", path)
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		go d.serve(conn)
+	}
+
+	// Quitting ends the running session like quitting the app does.
+	d.mu.Lock()
+	d.endSession(false)
+	d.mu.Unlock()
+	closeQueue(d.queue)
+	return nil
+}
+
+// serve answers a single request.
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 5))
+
+	var req daemonRequest
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	if err != nil {
+		json.NewEncoder(conn).Encode(daemonReply{Error: "bad request"})
+		return
+	}
+
+	json.NewEncoder(conn).Encode(d.handle(req.Command))
+}
+
+// handle runs a command and reports the resulting state.
+func (d *daemon) handle(command string) daemonReply {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	var reply daemonReply
+	switch command {
+	case daemonStart:
+		switch d.pomo.State() {
+		case pomodoro.Idle:
+			d.pomo.Start(now)
+			d.beginSession(now)
+		case pomodoro.Paused:
+			d.pomo.Start(now)
+		case pomodoro.Done:
+			d.next(now)
+		}
+	case daemonPause:
+		d.pomo.Pause(now)
+	case daemonSkip:
+		d.endSession(false)
+		d.next(now)
+	case daemonStatus:
+	default:
+		reply.Error = fmt.Sprintf("unknown command %q", command)
+	}
+	d.schedule(now)
+	d.phaseChanged(now)
+
+	reply.State = d.pomo.State().String()
+	reply.Kind = d.phase()
+	reply.Phase = phaseOf(d.pomo.State())
+	reply.Remaining = d.pomo.Remaining(now)
+	reply.Length = d.pomo.Length()
+	reply.Completed = d.pomo.CompletedOn(now)
+	return reply
+}
+
+// next starts the phase following the current one.
+func (d *daemon) next(now time.Time) {
+	if d.pomo.OnBreak() {
+		d.pomo.StartWork(now)
+	} else {
+		d.pomo.StartBreak(now)
+	}
+	d.beginSession(now)
+}
+
+// schedule arms the timer ending the running phase.
+func (d *daemon) schedule(now time.Time) {
+	if d.expiry != nil {
+		d.expiry.Stop()
+		d.expiry = nil
+	}
+	if d.pomo.Running() {
+		d.expiry = time.AfterFunc(d.pomo.Remaining(now), d.expire)
+	}
+}
+
+// expire finishes the running phase once its deadline passed.
+func (d *daemon) expire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	// The deadline is on the wall clock, which may have moved.
+	if !d.pomo.Running() || d.pomo.Remaining(now) > 0 {
+		d.schedule(now)
+		return
+	}
+
+	d.pomo.Finish(now)
+	d.endSession(true)
+
+	title, body := "Work session finished", "Time for a break."
+	if d.pomo.OnBreak() {
+		title, body = "Break finished", "Back to work."
+	} else if d.pomo.LongBreakDue() {
+		body = "Time for a long break."
+	}
+	d.publish(events.PhaseEnded{At: now, Title: title, Body: body})
+
+	if d.cfg.AutoStart {
+		d.next(now)
+		d.schedule(now)
+	}
+	d.phaseChanged(now)
+}
+
+// phaseChanged announces the phase if it changed since it was last
+// announced.
+func (d *daemon) phaseChanged(now time.Time) {
+	phase := phaseOf(d.pomo.State())
+	if phase == d.shown {
+		return
+	}
+	d.publish(events.PhaseChanged{At: now, From: d.shown, To: phase, Remaining: d.pomo.Remaining(now)})
+	d.shown = phase
+}
+
+// beginSession marks the start of a phase.
+func (d *daemon) beginSession(now time.Time) {
+	d.sessionStart = now
+	d.publish(events.SessionStarted{Start: now, Phase: d.phase()})
+}
+
+// endSession ends the running phase, if any.
+func (d *daemon) endSession(completed bool) {
+	if d.sessionStart.IsZero() {
+		return
+	}
+
+	now := time.Now()
+	session := history.Session{
+		Start:     d.sessionStart,
+		End:       now,
+		Phase:     d.phase(),
+		Duration:  d.pomo.Length() - d.pomo.Remaining(now),
+		Completed: completed,
+	}
+	d.sessionStart = time.Time{}
+	d.publish(events.SessionEnded{Session: session})
+}
+
+// publish queues e for its subscribers, which take their time, and logs
+// their errors.
+func (d *daemon) publish(e events.Event) {
+	result := d.queue.Add(e)
+	go func() {
+		if err := <-result; err != nil {
+			log.Printf("%s: %v", e.Name(), err)
+		}
+	}()
+}
+
+// phase names the phase being timed for the history.
+func (d *daemon) phase() string {
+	return kindName(d.pomo.Kind())
+}
+
+// kindName names a kind of phase the way the history does.
+func kindName(kind pomodoro.State) string {
+	switch kind {
+	case pomodoro.LongBreak:
+		return "long break"
+	case pomodoro.ShortBreak:
+		return phaseBreak
+	default:
+		return phaseWork
+	}
+}
+
+// errNoDaemon is returned by askDaemon when no daemon is running.
+var errNoDaemon = errors.New("no daemon running, start one with: pomodoro daemon")
+
+// askDaemon sends a command to the daemon and returns its reply.
+func askDaemon(command string) (daemonReply, error) {
+	var reply daemonReply
+	path, err := socketPath()
+	if err != nil {
+		return reply, err
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second*5)
+	if err != nil {
+		return reply, errNoDaemon
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second * 5))
+
+	if err := json.NewEncoder(conn).Encode(daemonRequest{Command: command}); err != nil {
+		return reply, err
+	}
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return reply, err
+	}
+	if reply.Error != "" {
+		return reply, errors.New(reply.Error)
+	}
+	return reply, nil
+}
+
+// runClient sends a command to the daemon and prints the timer's state.
+func runClient(command string, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: pomodoro %s", command)
+	}
+
+	reply, err := askDaemon(command)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	what := reply.State
+	switch reply.State {
+	case pomodoro.Idle.String(), pomodoro.Paused.String(), pomodoro.Done.String():
+		what = reply.Kind + ", " + reply.State
+	}
+	fmt.Printf("%s, %s left, %d pomodoros today\n", what, formatDuration(reply.Remaining, cfg.TimeFormat), reply.Completed)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"charm/test/internal/events"
+	"charm/test/internal/pomodoro"
+)
+
+// TestDaemonConcurrent runs commands from many clients while phases of a
+// millisecond keep expiring, for the race detector to check the daemon's
+// locking.
+func TestDaemonConcurrent(t *testing.T) {
+	bus := events.NewBus()
+	var published atomic.Int64
+	bus.SubscribeAll("count", func(context.Context, events.Event) error {
+		published.Add(1)
+		return nil
+	})
+
+	cfg := defaultConfig()
+	cfg.AutoStart = true
+	d := &daemon{
+		cfg:   cfg,
+		queue: events.NewQueue(bus, time.Second),
+		pomo: pomodoro.New(pomodoro.Config{
+			Work:              time.Millisecond,
+			ShortBreak:        time.Millisecond,
+			LongBreak:         time.Millisecond,
+			LongBreakInterval: 2,
+		}),
+	}
+
+	commands := []string{daemonStart, daemonPause, daemonSkip, daemonStatus}
+	var wg sync.WaitGroup
+	for client := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				reply := d.handle(commands[(client+i)%len(commands)])
+				if reply.Error != "" {
+					t.Errorf("handle() = %q", reply.Error)
+					return
+				}
+				if reply.Remaining < 0 || reply.Remaining > reply.Length {
+					t.Errorf("handle() = %v left of %v", reply.Remaining, reply.Length)
+					return
+				}
+				if i%10 == 0 {
+					d.expire()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A paused phase keeps expiries already under way from arming the
+	// timer again.
+	d.mu.Lock()
+	d.pomo.Pause(time.Now())
+	d.schedule(time.Now())
+	d.endSession(false)
+	d.mu.Unlock()
+	closeQueue(d.queue)
+
+	if published.Load() == 0 {
+		t.Error("no events published")
+	}
+}
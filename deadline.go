@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// deadline is a hard stop like a meeting or an exam, counted down next to
+// the pomodoro.
+type deadline struct {
+	label string
+	at    time.Time
+}
+
+// parseDeadline parses "16:00" or "16:00 demo". A time that has already
+// passed today refers to tomorrow.
+func parseDeadline(now time.Time, s string) (deadline, error) {
+	hhmm, label, _ := strings.Cut(strings.TrimSpace(s), " ")
+
+	at, err := clockTime(now, hhmm)
+	if err != nil {
+		return deadline{}, fmt.Errorf("deadline: %w", err)
+	}
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+
+	label = strings.TrimSpace(label)
+	if label == "" {
+		label = "Deadline"
+	}
+	return deadline{label: label, at: at}, nil
+}
+
+// deadlineView shows the time left until the deadline, rounded up to the
+// minute.
+func (m model) deadlineView() string {
+	if m.deadline.at.IsZero() {
+		return ""
+	}
+
+	left := time.Until(m.deadline.at)
+	if left <= 0 {
+		return fmt.Sprintf("%s at %s: now", m.deadline.label, m.deadline.at.Format("15:04"))
+	}
+	left = (left + time.Minute - 1).Truncate(time.Minute)
+
+	return fmt.Sprintf("%s at %s: %s left", m.deadline.label, m.deadline.at.Format("15:04"),
+		formatDuration(left, formatCompact))
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/events"
+	"charm/test/internal/pomodoro"
+	"charm/test/internal/termcap"
+)
+
+// debugEvents is how many of the latest events a debug snapshot holds.
+const debugEvents = 100
+
+// eventLog keeps the latest events for debug snapshots.
+type eventLog struct {
+	mu     sync.Mutex
+	events []loggedEvent
+}
+
+type loggedEvent struct {
+	At    time.Time    `json:"at"`
+	Name  string       `json:"name"`
+	Event events.Event `json:"event"`
+}
+
+// record is subscribed to every event.
+func (l *eventLog) record(_ context.Context, e events.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, loggedEvent{At: time.Now(), Name: e.Name(), Event: e})
+	if len(l.events) > debugEvents {
+		l.events = slices.Clone(l.events[len(l.events)-debugEvents:])
+	}
+	return nil
+}
+
+func (l *eventLog) latest() []loggedEvent {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.events)
+}
+
+// debugModel is the part of the model a debug snapshot shows.
+type debugModel struct {
+	Phase          string            `json:"phase"`
+	Machine        pomodoro.Snapshot `json:"machine"`
+	CompletedToday int               `json:"completed_today"`
+	Countdown      string            `json:"countdown"`
+	TimerRunning   bool              `json:"timer_running"`
+	SessionStart   time.Time         `json:"session_start"`
+	Intention      string            `json:"intention,omitempty"`
+	Task           string            `json:"task,omitempty"`
+	Segment        int               `json:"segment,omitempty"`
+	Segments       int               `json:"segments,omitempty"`
+	Kiosk          bool              `json:"kiosk"`
+	PairMode       bool              `json:"pair_mode"`
+	PausedBy       string            `json:"paused_by,omitempty"`
+	Prompts        []string          `json:"prompts"`
+	Parked         int               `json:"parked_ideas"`
+	Interrupts     map[string]int    `json:"interrupts"`
+	Undo           int               `json:"undo_depth"`
+	JobErr         string            `json:"job_error,omitempty"`
+	Layout         uiState           `json:"layout"`
+}
+
+// debugTerminal describes the terminal and the system.
+type debugTerminal struct {
+	Capabilities termcap.Capabilities `json:"capabilities"`
+	Width        int                  `json:"width"`
+	Height       int                  `json:"height"`
+	Env          map[string]string    `json:"env"`
+	OS           string               `json:"os"`
+	Arch         string               `json:"arch"`
+	GoVersion    string               `json:"go_version"`
+	Version      string               `json:"version"`
+}
+
+// debugEnv are the environment variables telling terminals apart.
+var debugEnv = []string{
+	"TERM", "COLORTERM", "TERM_PROGRAM", "TERM_PROGRAM_VERSION", "TMUX", "STY",
+	"LANG", "LC_ALL", "LC_CTYPE", "NO_COLOR", "CI", "SSH_CONNECTION", "WT_SESSION",
+}
+
+func (m model) debugModel() debugModel {
+	d := debugModel{
+		Phase:          m.phase(),
+		Machine:        m.pomo.Snapshot(),
+		CompletedToday: m.completedToday(),
+		Countdown:      m.timer.Timeout.String(),
+		TimerRunning:   m.timer.Running(),
+		SessionStart:   m.sessionStart,
+		Intention:      m.intention,
+		Task:           m.task,
+		Segment:        m.segment,
+		Segments:       len(m.intervals),
+		Kiosk:          m.kiosk,
+		PairMode:       m.pairMode,
+		PausedBy:       m.pausedBy,
+		Prompts:        []string{},
+		Parked:         len(m.parked),
+		Interrupts:     m.interrupts,
+		Undo:           len(m.undo),
+		Layout:         m.state(),
+	}
+	if m.jobErr != nil {
+		d.JobErr = m.jobErr.Error()
+	}
+	for prompt, open := range map[string]bool{
+		"resume":       m.askingResume,
+		"pause reason": m.askingReason,
+		"review":       m.askingReview,
+		"intention":    m.askingIntention,
+		"rename":       m.renaming,
+		"tasks":        m.choosingTask,
+		"add task":     m.addingTask,
+		"park":         m.capturing,
+	} {
+		if open {
+			d.Prompts = append(d.Prompts, prompt)
+		}
+	}
+	slices.Sort(d.Prompts)
+	return d
+}
+
+func (m model) debugTerminal() debugTerminal {
+	t := debugTerminal{
+		Capabilities: m.caps,
+		Width:        m.width,
+		Height:       m.height,
+		Env:          map[string]string{},
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		GoVersion:    runtime.Version(),
+	}
+	for _, name := range debugEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			t.Env[name] = v
+		}
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		t.Version = info.Main.Version
+	}
+	return t
+}
+
+// debugFiles returns the files of a debug snapshot by name.
+func (m model) debugFiles() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, v := range map[string]any{
+		"model.json":    m.debugModel(),
+		"terminal.json": m.debugTerminal(),
+		"events.json":   m.recent.latest(),
+	} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[name] = append(data, '\n')
+	}
+
+	var cfg strings.Builder
+	if err := toml.NewEncoder(&cfg).Encode(m.cfg.redacted()); err != nil {
+		return nil, fmt.Errorf("config.toml: %w", err)
+	}
+	files["config.toml"] = []byte(cfg.String())
+	return files, nil
+}
+
+// writeZip writes the files to a new zip archive at path, only readable
+// by the user since they hold the session's intention and task.
+func writeZip(path string, files map[string][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(f)
+	now := time.Now()
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err == nil {
+			_, err = w.Write(files[name])
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// saveDebugSnapshot writes the model, the config without its secrets, the
+// terminal and the latest events to a zip in the data directory, to attach
+// to bug reports.
+func (m model) saveDebugSnapshot() (tea.Model, tea.Cmd) {
+	dir, err := dataDir()
+	if err != nil {
+		m.jobErr = err
+		return m, nil
+	}
+	files, err := m.debugFiles()
+	if err != nil {
+		m.jobErr = fmt.Errorf("debug snapshot failed: %w", err)
+		return m, nil
+	}
+
+	path := filepath.Join(dir, "debug-"+time.Now().Format("20060102-150405")+".zip")
+	saveCmd := m.jobs.Run("debug snapshot", time.Second*10, func(context.Context) error {
+		return writeZip(path, files)
+	})
+
+	m, toastCmd := m.showToast("Saving debug snapshot to " + path)
+	return m, tea.Batch(saveCmd, toastCmd)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"charm/test/internal/notify"
+)
+
+// runDoctor implements the "doctor" subcommand, which checks that the
+// integrations work on this system.
+func runDoctor(args []string) error {
+	if len(args) == 0 || args[0] != "notify" {
+		return errors.New("usage: pomodoro doctor notify [--backend name] [--command cmd] [--test]")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("doctor notify", flag.ContinueOnError)
+	backend := fs.String("backend", cfg.NotifyBackend, "notification backend to check, detected when empty")
+	command := fs.String("command", cfg.NotifyCommand, "command for the command backend, may use {title} and {body}")
+	test := fs.Bool("test", false, "send a test notification")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	n, err := notify.ByName(*backend, *command)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Notification backend:", n.Name())
+
+	if !*test {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if err := n.Notify(ctx, "Pomodoro", "Test notification from pomodoro doctor"); err != nil {
+		return fmt.Errorf("sending test notification: %w", err)
+	}
+	fmt.Println("Test notification sent. If nothing showed up, check your notification settings or pick another --backend.")
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"charm/test/internal/indicator"
+	"charm/test/internal/notify"
+)
+
+// dryRun makes the integrations log what they would do instead of doing
+// it, so a new config can be tried during a real session. It's set by
+// --dry-run.
+var dryRun bool
+
+// dryNotifier logs notifications instead of sending them.
+type dryNotifier struct {
+	notify.Notifier
+}
+
+func (n dryNotifier) Notify(_ context.Context, title, body string) error {
+	log.Printf("dry run: %s notification %q: %s", n.Name(), title, body)
+	return nil
+}
+
+// dryIndicator logs the phases it's handed instead of showing them.
+type dryIndicator struct {
+	name string
+}
+
+func (d dryIndicator) Show(_ context.Context, phase string) error {
+	log.Printf("dry run: %s shows %s", d.name, phase)
+	return nil
+}
+
+// indicate returns ind, or an indicator logging for it in a dry run.
+func indicate(name string, ind indicator.Indicator) indicator.Indicator {
+	if dryRun {
+		return dryIndicator{name}
+	}
+	return ind
+}
+
+// dryTasks reads the tasks of a provider but only logs changes to them.
+type dryTasks struct {
+	p       taskProvider
+	service string
+}
+
+func (t dryTasks) List(ctx context.Context) ([]string, error) {
+	return t.p.List(ctx)
+}
+
+func (t dryTasks) Add(_ context.Context, name string) error {
+	if _, ok := t.p.(taskAdder); !ok {
+		return errNoAdding
+	}
+	log.Printf("dry run: %s adds task %q", t.service, name)
+	return nil
+}
+
+func (t dryTasks) Complete(_ context.Context, name string) error {
+	log.Printf("dry run: %s completes task %q", t.service, name)
+	return nil
+}
+
+func (t dryTasks) Annotate(_ context.Context, name, text string) error {
+	log.Printf("dry run: %s notes on task %q: %s", t.service, name, text)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"charm/test/internal/history"
+)
+
+// exportedSession is a session as written by the export subcommand.
+type exportedSession struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Phase       string    `json:"phase"`
+	Seconds     int64     `json:"duration_seconds"`
+	Completed   bool      `json:"completed"`
+	Intention   string    `json:"intention,omitempty"`
+	Task        string    `json:"task,omitempty"`
+	Interrupted bool      `json:"interrupted,omitempty"`
+	Review      string    `json:"review,omitempty"`
+}
+
+// runExport implements the "export" subcommand.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "output format: csv, json or org")
+	from := fs.String("from", "", "first day to export (YYYY-MM-DD), defaults to all history")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: pomodoro export [--format csv|json|org] [--from 2024-01-01]")
+	}
+	if *format != "csv" && *format != "json" && *format != "org" {
+		return fmt.Errorf("unknown format %q, expected csv, json or org", *format)
+	}
+
+	start := time.Unix(0, 0)
+	if *from != "" {
+		t, err := time.ParseInLocation(time.DateOnly, *from, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --from %q, expected YYYY-MM-DD", *from)
+		}
+		start = t
+	}
+
+	store, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	sessions, err := store.Between(ctx, start, time.Now().Add(time.Second))
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		return exportJSON(sessions)
+	case "org":
+		return exportOrg(sessions)
+	default:
+		return exportCSV(sessions)
+	}
+}
+
+func exportJSON(sessions []history.Session) error {
+	rows := make([]exportedSession, len(sessions))
+	for i, s := range sessions {
+		rows[i] = exportedSession{
+			Start:       s.Start,
+			End:         s.End,
+			Phase:       s.Phase,
+			Seconds:     int64(s.Duration / time.Second),
+			Completed:   s.Completed,
+			Intention:   s.Intention,
+			Task:        s.Task,
+			Interrupted: s.Interrupted,
+			Review:      s.Review,
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+func exportCSV(sessions []history.Session) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"start", "end", "phase", "duration_seconds", "completed", "intention", "task", "interrupted", "review"})
+	for _, s := range sessions {
+		w.Write([]string{
+			s.Start.Format(time.RFC3339),
+			s.End.Format(time.RFC3339),
+			s.Phase,
+			strconv.FormatInt(int64(s.Duration/time.Second), 10),
+			strconv.FormatBool(s.Completed),
+			s.Intention,
+			s.Task,
+			strconv.FormatBool(s.Interrupted),
+			s.Review,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// orgTimestamp is the format of Org-mode inactive timestamps.
+const orgTimestamp = "2006-01-02 Mon 15:04"
+
+// exportOrg writes work sessions as CLOCK entries in a LOGBOOK drawer
+// under a heading per task, for Org-mode clock reports. Sessions without
+// a task are filed under their intention.
+func exportOrg(sessions []history.Session) error {
+	var headings []string
+	clocks := map[string][]history.Session{}
+	for _, s := range sessions {
+		if s.Phase != phaseWork {
+			continue
+		}
+		heading := s.Task
+		if heading == "" {
+			heading = s.Intention
+		}
+		if heading == "" {
+			heading = "Pomodoro"
+		}
+		if _, ok := clocks[heading]; !ok {
+			headings = append(headings, heading)
+		}
+		clocks[heading] = append(clocks[heading], s)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	for _, heading := range headings {
+		fmt.Fprintf(w, "* %s\n:LOGBOOK:\n", heading)
+		// Org lists the most recent clock first.
+		for i := len(clocks[heading]) - 1; i >= 0; i-- {
+			s := clocks[heading][i]
+			minutes := int(s.End.Sub(s.Start).Minutes())
+			fmt.Fprintf(w, "CLOCK: [%s]--[%s] => %2d:%02d\n",
+				s.Start.Format(orgTimestamp), s.End.Format(orgTimestamp), minutes/60, minutes%60)
+		}
+		fmt.Fprintln(w, ":END:")
+	}
+	return w.Flush()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// timeFormat selects how durations are rendered.
+type timeFormat string
+
+const (
+	formatClock     timeFormat = "mm:ss"
+	formatLongClock timeFormat = "h:mm:ss"
+	formatCompact   timeFormat = "compact"
+	formatLocalized timeFormat = "localized"
+)
+
+// durationFormat is used for the countdown and everywhere else a
+// remaining time is shown.
+var durationFormat = formatClock
+
+// unitNames holds singular and plural unit names per language. Only the
+// names are translated: numbers and word order stay the same, and other
+// languages fall back to English.
+var unitNames = map[string][3][2]string{
+	"en": {{"hour", "hours"}, {"minute", "minutes"}, {"second", "seconds"}},
+	"de": {{"Stunde", "Stunden"}, {"Minute", "Minuten"}, {"Sekunde", "Sekunden"}},
+	"fr": {{"heure", "heures"}, {"minute", "minutes"}, {"seconde", "secondes"}},
+	"es": {{"hora", "horas"}, {"minuto", "minutos"}, {"segundo", "segundos"}},
+}
+
+// formatDuration renders d in the given format. Negative durations are
+// shown as zero.
+func formatDuration(d time.Duration, f timeFormat) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Second)
+
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := int(d % time.Minute / time.Second)
+
+	switch f {
+	case formatLongClock:
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	case formatCompact:
+		if d < time.Minute {
+			return fmt.Sprintf("%ds", s)
+		}
+		// Round partial minutes up so "25m" is shown until the first
+		// full minute has passed.
+		mins := int((d + time.Minute - time.Second) / time.Minute)
+		if mins < 60 {
+			return fmt.Sprintf("%dm", mins)
+		}
+		return fmt.Sprintf("%dh%02dm", mins/60, mins%60)
+	case formatLocalized:
+		names := unitNames[language()]
+		var parts []string
+		for i, n := range []int{h, m, s} {
+			if n == 0 {
+				continue
+			}
+			name := names[i][1]
+			if n == 1 {
+				name = names[i][0]
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, name))
+		}
+		if len(parts) == 0 {
+			return "0 " + names[2][1]
+		}
+		return strings.Join(parts, " ")
+	default:
+		return fmt.Sprintf("%02d:%02d", h*60+m, s)
+	}
+}
+
+// language returns the user's language from the locale environment,
+// defaulting to English.
+func language() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			lang := strings.ToLower(v[:min(2, len(v))])
+			if _, ok := unitNames[lang]; ok {
+				return lang
+			}
+			return "en"
+		}
+	}
+	return "en"
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Progress styles.
+const (
+	progressBar  = "bar"
+	progressRing = "ring"
+)
+
+const (
+	// ringCols and ringRows size the ring in cells. Terminal cells are
+	// about twice as high as wide, so this makes a circle.
+	ringCols = 16
+	ringRows = 8
+	// ringWidth is the thickness of the ring as a share of its radius.
+	ringWidth = 0.25
+)
+
+// brailleDots are the bits of the braille dots in a cell, by row and
+// column.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// ring renders progress, between 0 and 1, as a ring filling clockwise from
+// the top, in a gradient from the start to the end color. Braille dots
+// draw it finely; without Unicode it's drawn with '#' on a track of '.'.
+func ring(progress float64, start, end, track string, unicode bool) string {
+	from, err1 := colorful.Hex(start)
+	to, err2 := colorful.Hex(end)
+	gradient := err1 == nil && err2 == nil
+
+	// Dots per cell.
+	dx, dy := 1, 1
+	if unicode {
+		dx, dy = 2, 4
+	}
+	w, h := float64(ringCols*dx), float64(ringRows*dy)
+
+	// at reports whether the dot at x, y is on the ring and how far round
+	// it is.
+	at := func(x, y int) (float64, bool) {
+		// Scaled to a unit circle, from the middle of the dot.
+		px := (float64(x)+0.5)/w*2 - 1
+		py := (float64(y)+0.5)/h*2 - 1
+		r := math.Hypot(px, py)
+		if r > 1 || r < 1-ringWidth {
+			return 0, false
+		}
+		turn := math.Atan2(px, -py) / (2 * math.Pi)
+		if turn < 0 {
+			turn++
+		}
+		return turn, true
+	}
+
+	trackStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(track))
+	lines := make([]string, ringRows)
+	for row := range ringRows {
+		var line strings.Builder
+		for col := range ringCols {
+			var filled, empty rune
+			var turn float64
+			for y := range dy {
+				for x := range dx {
+					px, py := col*dx+x, row*dy+y
+					t, ok := at(px, py)
+					switch {
+					case !ok:
+					case t <= progress:
+						filled |= brailleDots[y][x]
+						turn = max(turn, t)
+					case !unicode || (px+py)%2 == 0:
+						// Every other dot of the track, so it
+						// stands apart from the filled part even
+						// without colors.
+						empty |= brailleDots[y][x]
+					}
+				}
+			}
+
+			// A cell shows the filled part of the ring, if it has
+			// any, and the track otherwise.
+			switch {
+			case filled != 0:
+				ch := "#"
+				if unicode {
+					ch = string(0x2800 + filled)
+				}
+				color := lipgloss.Color(start)
+				if gradient {
+					color = lipgloss.Color(from.BlendLuv(to, turn).Hex())
+				}
+				line.WriteString(lipgloss.NewStyle().Foreground(color).Render(ch))
+			case empty != 0:
+				ch := "."
+				if unicode {
+					ch = string(0x2800 + empty)
+				}
+				line.WriteString(trackStyle.Render(ch))
+			default:
+				line.WriteString(" ")
+			}
+		}
+		lines[row] = line.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// progressView renders the progress of the phase in the configured style.
+func (m model) progressView() string {
+	if m.cfg.ProgressStyle == progressRing {
+		c := m.cfg.Colors
+		return ring(m.elapsed(), c.ProgressStart, c.ProgressEnd, c.Border, m.caps.Unicode)
+	}
+	return m.progress.View()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// completedToday is the number of pomodoros finished today.
+func (m model) completedToday() int {
+	return m.pomo.CompletedOn(time.Now())
+}
+
+// goalJustReached reports whether the last pomodoro reached the daily goal.
+func (m model) goalJustReached() bool {
+	return m.cfg.DailyGoal > 0 && m.completedToday() == m.cfg.DailyGoal
+}
+
+// goalView shows the progress toward the daily goal.
+func (m model) goalView() string {
+	goal := m.cfg.DailyGoal
+	if goal <= 0 {
+		return ""
+	}
+
+	done := m.completedToday()
+	text := fmt.Sprintf("Daily goal %d/%d", done, goal)
+	if done >= goal {
+		text += ", reached!"
+	}
+	return "\n" + m.goalProgress.ViewAs(min(1, float64(done)/float64(goal))) + "  " + text
+}
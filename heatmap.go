@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"charm/test/internal/history"
+)
+
+// heatmapWeeks is how far back the heatmap goes.
+const heatmapWeeks = 17
+
+// heatmapShades color a day by its number of completed pomodoros, from
+// none to eight or more.
+var heatmapShades = []struct {
+	min   int
+	color string
+}{
+	{0, "237"},
+	{1, "#3B3880"},
+	{3, "#5A56E0"},
+	{5, "#A062EC"},
+	{8, "#EE6FF8"},
+}
+
+// heatmap renders completed pomodoros per day as a contribution calendar
+// with a column per week, ending with the current week.
+func heatmap(sessions []history.Session, now time.Time, unicode bool) string {
+	counts := map[string]int{}
+	total := 0
+	for _, s := range sessions {
+		if s.Phase == phaseWork && s.Completed {
+			counts[s.Start.Format(time.DateOnly)]++
+			total++
+		}
+	}
+
+	cell := "■ "
+	if !unicode {
+		cell = "# "
+	}
+	shade := func(n int) string {
+		color := heatmapShades[0].color
+		for _, s := range heatmapShades {
+			if n >= s.min {
+				color = s.color
+			}
+		}
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(cell)
+	}
+
+	first := startOfWeek(now).AddDate(0, 0, -7*(heatmapWeeks-1))
+
+	months := []byte(strings.Repeat(" ", heatmapWeeks*2+4))
+	for w := 0; w < heatmapWeeks; w++ {
+		day := first.AddDate(0, 0, 7*w)
+		if w == 0 || day.Month() != day.AddDate(0, 0, -7).Month() {
+			label := day.Format("Jan")
+			if at := 4 + w*2; at+len(label) <= len(months) {
+				copy(months[at:], label)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(string(months), " ") + "\n")
+	for weekday, name := range []string{"Mon", "", "Wed", "", "Fri", "", "Sun"} {
+		fmt.Fprintf(&b, "%-4s", name)
+		for w := 0; w < heatmapWeeks; w++ {
+			day := first.AddDate(0, 0, 7*w+weekday)
+			if day.After(now) {
+				break
+			}
+			b.WriteString(shade(counts[day.Format(time.DateOnly)]))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n    Less ")
+	for _, s := range heatmapShades {
+		b.WriteString(shade(s.min))
+	}
+	fmt.Fprintf(&b, "More    %d pomodoros since %s", total, first.Format("Jan 2"))
+	return b.String()
+}
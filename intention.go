@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	confirmIntention = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "start"),
+	)
+	skipIntention = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "start without"),
+	)
+	saveName = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "save"),
+	)
+)
+
+func newIntentionInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "What will you get done this pomodoro?"
+	ti.CharLimit = 120
+	ti.Width = 60
+	return ti
+}
+
+// promptIntention asks for the session's intention before work starts,
+// suggesting the name given with renameSession, if any.
+func (m model) promptIntention() (tea.Model, tea.Cmd) {
+	m.askingIntention = true
+	m.intentionInput.SetValue(m.intention)
+	m.intentionInput.CursorEnd()
+	return m, m.intentionInput.Focus()
+}
+
+// renameSession opens the intention input to name the current session
+// without starting or restarting it.
+func (m model) renameSession() (tea.Model, tea.Cmd) {
+	m.askingIntention = true
+	m.renaming = true
+	m.intentionInput.SetValue(m.intention)
+	m.intentionInput.CursorEnd()
+	return m, m.intentionInput.Focus()
+}
+
+// updateIntention edits the intention until the work session is started.
+func (m model) updateIntention(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.renaming {
+		return m.updateRename(msg)
+	}
+
+	switch {
+	case key.Matches(msg, confirmIntention):
+		m.intention = strings.TrimSpace(m.intentionInput.Value())
+	case key.Matches(msg, skipIntention):
+		m.intention = ""
+	default:
+		var cmd tea.Cmd
+		m.intentionInput, cmd = m.intentionInput.Update(msg)
+		return m, cmd
+	}
+
+	m.askingIntention = false
+	m.intentionInput.Blur()
+	return m.beginWork()
+}
+
+// updateRename edits the name of the current session.
+func (m model) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, confirmIntention):
+		m.intention = strings.TrimSpace(m.intentionInput.Value())
+	case key.Matches(msg, cancelIdea):
+	default:
+		var cmd tea.Cmd
+		m.intentionInput, cmd = m.intentionInput.Update(msg)
+		return m, cmd
+	}
+
+	m.askingIntention = false
+	m.renaming = false
+	m.intentionInput.Blur()
+	return m, nil
+}
+
+// intentionView shows the intention under the countdown while working.
+func (m model) intentionView() string {
+	if m.intention == "" || m.pomo.OnBreak() {
+		return ""
+	}
+
+	return "\n" + lipgloss.NewStyle().Italic(true).Render(m.intention)
+}
+
+// intentionPromptView shows the input asking for the intention.
+func (m model) intentionPromptView() string {
+	if m.renaming {
+		return "\n" + m.intentionInput.View() + "\n" +
+			m.help.ShortHelpView([]key.Binding{saveName, cancelIdea})
+	}
+	return "\n" + m.intentionInput.View() + "\n" +
+		m.help.ShortHelpView([]key.Binding{confirmIntention, skipIntention})
+}
@@ -0,0 +1,103 @@
+// Package audio plays alert sounds in-process where it can, otherwise
+// through whatever the host system offers, falling back to the terminal
+// bell.
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Format is the encoding of a sound file.
+type Format int
+
+const (
+	WAV Format = iota
+	MP3
+)
+
+func (f Format) String() string {
+	if f == MP3 {
+		return "MP3"
+	}
+	return "WAV"
+}
+
+// ErrUnsupported is returned by Sniff for files that are neither WAV nor
+// MP3.
+var ErrUnsupported = errors.New("not a WAV or MP3 file")
+
+// Sniff returns the format of the sound file at path, judging by its first
+// bytes rather than its name.
+func Sniff(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(f, head)
+	format, ok := sniff(head[:n])
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", path, ErrUnsupported)
+	}
+	return format, nil
+}
+
+func sniff(head []byte) (Format, bool) {
+	switch {
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return WAV, true
+	case len(head) >= 3 && string(head[:3]) == "ID3",
+		// A bare MPEG audio frame starts with 11 set bits.
+		len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0:
+		return MP3, true
+	}
+	return 0, false
+}
+
+// Player plays a sound file.
+type Player interface {
+	// Name identifies the backend, e.g. "paplay" or "bell".
+	Name() string
+	// Play plays the file at path and returns once playback finished or
+	// ctx is done.
+	Play(ctx context.Context, path string) error
+}
+
+// Detect returns the best player available on this system for files of
+// format f. It prefers playing in-process, then a native API, then an
+// external player, and falls back to ringing the bell on w.
+func Detect(w io.Writer, f Format) Player {
+	if p, ok := detectOto(); ok {
+		return p
+	}
+	if p, ok := detectNative(); ok && f == WAV {
+		return p
+	}
+	if p, ok := detectExec(f); ok {
+		return p
+	}
+	return Bell(w)
+}
+
+type bell struct {
+	w io.Writer
+}
+
+// Bell returns a player that ignores the file and rings the terminal
+// bell instead.
+func Bell(w io.Writer) Player {
+	return bell{w}
+}
+
+func (b bell) Name() string { return "bell" }
+
+func (b bell) Play(context.Context, string) error {
+	_, err := io.WriteString(b.w, "\a")
+	return err
+}
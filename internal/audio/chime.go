@@ -0,0 +1,47 @@
+package audio
+
+import (
+	"bytes"
+	_ "embed"
+	"os"
+	"path/filepath"
+)
+
+//go:embed chime.wav
+var chime []byte
+
+// ChimeFile returns the path of the default chime, writing it to the
+// user's cache directory first since players need a file to play. Unlike
+// the shared temporary directory, nobody else can plant a file there.
+func ChimeFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "pomodoro")
+	path := filepath.Join(dir, "chime.wav")
+	if data, err := os.ReadFile(path); err == nil && bytes.Equal(data, chime) {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	// Written aside and renamed, so a player never gets half of it.
+	f, err := os.CreateTemp(dir, "chime-*.wav")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(chime); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,177 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// pcm is decoded audio: interleaved samples between -1 and 1.
+type pcm struct {
+	rate     int
+	channels int
+	samples  []float32
+}
+
+// decode decodes a WAV or MP3 file.
+func decode(data []byte) (pcm, error) {
+	format, ok := sniff(data)
+	if !ok {
+		return pcm{}, ErrUnsupported
+	}
+	if format == MP3 {
+		return decodeMP3(data)
+	}
+	return decodeWAV(data)
+}
+
+// decodeMP3 decodes an MP3 file, which go-mp3 turns into 16 bit stereo.
+func decodeMP3(data []byte) (pcm, error) {
+	d, err := mp3.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return pcm{}, fmt.Errorf("mp3: %w", err)
+	}
+	raw, err := io.ReadAll(d)
+	if err != nil {
+		return pcm{}, fmt.Errorf("mp3: %w", err)
+	}
+
+	p := pcm{rate: d.SampleRate(), channels: 2, samples: make([]float32, len(raw)/2)}
+	for i := range p.samples {
+		p.samples[i] = float32(int16(binary.LittleEndian.Uint16(raw[i*2:]))) / (1 << 15)
+	}
+	return p, nil
+}
+
+// WAV format tags of the fmt chunk.
+const (
+	wavPCM        = 1
+	wavFloat      = 3
+	wavExtensible = 0xfffe
+)
+
+// decodeWAV decodes a WAV file of 8, 16, 24 or 32 bit integer or 32 bit
+// float samples.
+func decodeWAV(data []byte) (pcm, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return pcm{}, errors.New("wav: not a WAV file")
+	}
+
+	var (
+		p         pcm
+		tag       uint16
+		bits      int
+		fmtFound  bool
+		dataChunk []byte
+		dataFound bool
+	)
+	for rest := data[12:]; len(rest) >= 8; {
+		id, size := string(rest[:4]), int(binary.LittleEndian.Uint32(rest[4:8]))
+		rest = rest[8:]
+		if size > len(rest) {
+			// Files cut short while writing still play up to the cut.
+			size = len(rest)
+		}
+		chunk := rest[:size]
+		switch id {
+		case "fmt ":
+			if len(chunk) < 16 {
+				return pcm{}, errors.New("wav: short fmt chunk")
+			}
+			tag = binary.LittleEndian.Uint16(chunk[0:2])
+			p.channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			p.rate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			bits = int(binary.LittleEndian.Uint16(chunk[14:16]))
+			if tag == wavExtensible && len(chunk) >= 26 {
+				tag = binary.LittleEndian.Uint16(chunk[24:26])
+			}
+			fmtFound = true
+		case "data":
+			dataChunk, dataFound = chunk, true
+		}
+		// Chunks are padded to an even size.
+		rest = rest[min(len(rest), size+size%2):]
+	}
+	if !fmtFound || !dataFound {
+		return pcm{}, errors.New("wav: missing fmt or data chunk")
+	}
+	if p.channels < 1 || p.rate < 1 {
+		return pcm{}, fmt.Errorf("wav: %d channels at %d Hz", p.channels, p.rate)
+	}
+
+	sample, err := sampleDecoder(tag, bits)
+	if err != nil {
+		return pcm{}, err
+	}
+	width := bits / 8
+	frames := len(dataChunk) / (width * p.channels)
+	p.samples = make([]float32, frames*p.channels)
+	for i := range p.samples {
+		p.samples[i] = sample(dataChunk[i*width:])
+	}
+	return p, nil
+}
+
+// sampleDecoder returns a function decoding a single sample.
+func sampleDecoder(tag uint16, bits int) (func([]byte) float32, error) {
+	switch {
+	case tag == wavPCM && bits == 8:
+		return func(b []byte) float32 { return (float32(b[0]) - 128) / 128 }, nil
+	case tag == wavPCM && bits == 16:
+		return func(b []byte) float32 { return float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }, nil
+	case tag == wavPCM && bits == 24:
+		return func(b []byte) float32 {
+			return float32(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		}, nil
+	case tag == wavPCM && bits == 32:
+		return func(b []byte) float32 { return float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }, nil
+	case tag == wavFloat && bits == 32:
+		return func(b []byte) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(b)) }, nil
+	}
+	return nil, fmt.Errorf("wav: unsupported encoding %d with %d bit samples", tag, bits)
+}
+
+// convert resamples p to rate and mixes it to channels, which is 1 or 2,
+// returning the samples as 32 bit little endian floats.
+func (p pcm) convert(rate, channels int) []byte {
+	frames := len(p.samples) / p.channels
+	out := int(int64(frames) * int64(rate) / int64(p.rate))
+
+	buf := make([]byte, 0, out*channels*4)
+	for i := range out {
+		// Linear interpolation between the two nearest source frames.
+		pos := float64(i) * float64(p.rate) / float64(rate)
+		j, frac := int(pos), float32(pos-math.Floor(pos))
+		k := min(j+1, frames-1)
+		for c := range channels {
+			a, b := p.at(j, c, channels), p.at(k, c, channels)
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(a+(b-a)*frac))
+		}
+	}
+	return buf
+}
+
+// at returns channel c of frame i of p, played through channels
+// channels: mono is copied to both sides, stereo takes the first two
+// channels and mono mixes all of them.
+func (p pcm) at(i, c, channels int) float32 {
+	frame := p.samples[i*p.channels : (i+1)*p.channels]
+	switch {
+	case p.channels == channels:
+		return frame[c]
+	case p.channels == 1:
+		return frame[0]
+	case channels == 2:
+		return frame[min(c, p.channels-1)]
+	}
+	var sum float32
+	for _, s := range frame {
+		sum += s
+	}
+	return sum / float32(len(frame))
+}
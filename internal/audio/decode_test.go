@@ -0,0 +1,134 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// wav builds a WAV file of the given encoding around data.
+func wav(tag uint16, channels, rate, bits int, data []byte) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, tag)
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate*channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, uint16(bits))
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     []byte
+		channels int
+		want     []float32
+	}{
+		{"8 bit", wav(wavPCM, 1, 8000, 8, []byte{0, 128, 192}), 1, []float32{-1, 0, 0.5}},
+		{"16 bit stereo", wav(wavPCM, 2, 8000, 16, []byte{0x00, 0x80, 0x00, 0x40}), 2, []float32{-1, 0.5}},
+		{"24 bit", wav(wavPCM, 1, 8000, 24, []byte{0x00, 0x00, 0xc0}), 1, []float32{-0.5}},
+		{"float", wav(wavFloat, 1, 8000, 32, binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.25))), 1, []float32{0.25}},
+		{"odd byte dropped", wav(wavPCM, 1, 8000, 16, []byte{0x00, 0x40, 0x01}), 1, []float32{0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := decodeWAV(tt.file)
+			if err != nil {
+				t.Fatalf("decodeWAV() = %v", err)
+			}
+			if p.channels != tt.channels || p.rate != 8000 {
+				t.Errorf("decodeWAV() = %d channels at %d Hz, want %d at 8000", p.channels, p.rate, tt.channels)
+			}
+			if len(p.samples) != len(tt.want) {
+				t.Fatalf("decodeWAV() = %v, want %v", p.samples, tt.want)
+			}
+			for i := range tt.want {
+				if p.samples[i] != tt.want[i] {
+					t.Errorf("decodeWAV() = %v, want %v", p.samples, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWAVErrors(t *testing.T) {
+	for name, file := range map[string][]byte{
+		"empty":        nil,
+		"not riff":     []byte("ID3\x04\x00\x00\x00\x00\x00\x00\x00\x00"),
+		"no data":      wav(wavPCM, 1, 8000, 16, nil)[:36],
+		"12 bit":       wav(wavPCM, 1, 8000, 12, []byte{0, 0}),
+		"no channels":  wav(wavPCM, 0, 8000, 16, []byte{0, 0}),
+		"64 bit float": wav(wavFloat, 1, 8000, 64, make([]byte, 8)),
+	} {
+		if _, err := decodeWAV(file); err == nil {
+			t.Errorf("decodeWAV(%s) succeeded", name)
+		}
+	}
+}
+
+func TestDecodeChime(t *testing.T) {
+	p, err := decodeWAV(chime)
+	if err != nil {
+		t.Fatalf("decodeWAV(chime) = %v", err)
+	}
+	out := p.convert(44100, 2)
+	frames := len(p.samples) / p.channels
+	if want := frames * 44100 / p.rate * 2 * 4; len(out) != want {
+		t.Errorf("convert() = %d bytes, want %d", len(out), want)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	mono := pcm{rate: 2, channels: 1, samples: []float32{0, 1}}
+	got := mono.convert(4, 2)
+	var samples []float32
+	for i := 0; i < len(got); i += 4 {
+		samples = append(samples, math.Float32frombits(binary.LittleEndian.Uint32(got[i:])))
+	}
+	// Upsampled twice, interpolating in between, with both sides alike.
+	want := []float32{0, 0, 0.5, 0.5, 1, 1, 1, 1}
+	if len(samples) != len(want) {
+		t.Fatalf("convert() = %v, want %v", samples, want)
+	}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Fatalf("convert() = %v, want %v", samples, want)
+		}
+	}
+
+	stereo := pcm{rate: 1, channels: 2, samples: []float32{1, 0}}
+	got = stereo.convert(1, 1)
+	if s := math.Float32frombits(binary.LittleEndian.Uint32(got)); s != 0.5 {
+		t.Errorf("stereo to mono = %v, want 0.5", s)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name   string
+		head   []byte
+		want   Format
+		wantOK bool
+	}{
+		{"wav", wav(wavPCM, 1, 8000, 16, nil), WAV, true},
+		{"id3", []byte("ID3\x04\x00"), MP3, true},
+		{"mpeg frame", []byte{0xff, 0xfb, 0x90, 0x64}, MP3, true},
+		{"riff but not wave", []byte("RIFF\x00\x00\x00\x00AVI "), 0, false},
+		{"ogg", []byte("OggS\x00\x02"), 0, false},
+		{"flac", []byte("fLaC\x00\x00"), 0, false},
+		{"empty", nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sniff(tt.head)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("sniff() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
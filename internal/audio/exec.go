@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// execPlayer plays files by running an external command.
+type execPlayer struct {
+	name string
+	args func(path string) []string
+	// formats are the formats it plays; nil means all of them.
+	formats []Format
+}
+
+// wavOnly marks players that can't decode anything but WAV.
+var wavOnly = []Format{WAV}
+
+// candidates lists the external players tried per OS, in order of
+// preference.
+var candidates = map[string][]execPlayer{
+	"darwin": {
+		{name: "afplay", args: func(path string) []string { return []string{path} }},
+	},
+	"linux": {
+		// libsndfile, which paplay and pw-play decode with, only reads
+		// MP3 since version 1.1.
+		{name: "paplay", args: func(path string) []string { return []string{path} }, formats: wavOnly},
+		{name: "pw-play", args: func(path string) []string { return []string{path} }, formats: wavOnly},
+		{name: "aplay", args: func(path string) []string { return []string{"-q", path} }, formats: wavOnly},
+		{name: "ffplay", args: func(path string) []string { return []string{"-nodisp", "-autoexit", "-loglevel", "quiet", path} }},
+	},
+	"windows": {
+		{name: "powershell", args: func(path string) []string {
+			return []string{"-NoProfile", "-Command", "(New-Object Media.SoundPlayer '" + strings.ReplaceAll(path, "'", "''") + "').PlaySync()"}
+		}, formats: wavOnly},
+	},
+}
+
+// Exec returns a player running the named command with the file as its
+// only argument, for players not detected automatically.
+func Exec(name string) Player {
+	return execPlayer{name: name, args: func(path string) []string { return []string{path} }}
+}
+
+func detectExec(f Format) (Player, bool) {
+	for _, p := range candidates[runtime.GOOS] {
+		if p.formats != nil && !slices.Contains(p.formats, f) {
+			continue
+		}
+		if _, err := exec.LookPath(p.name); err == nil {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (p execPlayer) Name() string { return p.name }
+
+func (p execPlayer) Play(ctx context.Context, path string) error {
+	return exec.CommandContext(ctx, p.name, p.args(path)...).Run()
+}
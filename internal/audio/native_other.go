@@ -0,0 +1,7 @@
+//go:build !windows
+
+package audio
+
+func detectNative() (Player, bool) {
+	return nil, false
+}
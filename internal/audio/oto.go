@@ -0,0 +1,80 @@
+//go:build darwin || windows || oto
+
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// The format of the output, which every file is converted to since a
+// process can only open a single oto context.
+const (
+	otoRate     = 44100
+	otoChannels = 2
+)
+
+// otoPlayer plays WAV and MP3 files in-process through the system's audio API:
+// Core Audio, WASAPI or, built with the oto tag on other systems, ALSA.
+type otoPlayer struct {
+	ctx *oto.Context
+}
+
+var (
+	otoOnce    sync.Once
+	otoContext *oto.Context
+)
+
+func detectOto() (Player, bool) {
+	otoOnce.Do(func() {
+		ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   otoRate,
+			ChannelCount: otoChannels,
+			Format:       oto.FormatFloat32LE,
+		})
+		if err != nil {
+			return
+		}
+		<-ready
+		otoContext = ctx
+	})
+	if otoContext == nil {
+		return nil, false
+	}
+	return otoPlayer{otoContext}, true
+}
+
+func (otoPlayer) Name() string { return "oto" }
+
+func (p otoPlayer) Play(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sound, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	player := p.ctx.NewPlayer(bytes.NewReader(sound.convert(otoRate, otoChannels)))
+	defer player.Close()
+	player.Play()
+
+	tick := time.NewTicker(time.Millisecond * 50)
+	defer tick.Stop()
+	for player.IsPlaying() {
+		select {
+		case <-ctx.Done():
+			player.Pause()
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return player.Err()
+}
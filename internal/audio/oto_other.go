@@ -0,0 +1,7 @@
+//go:build !darwin && !windows && !oto
+
+package audio
+
+func detectOto() (Player, bool) {
+	return nil, false
+}
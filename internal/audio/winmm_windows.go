@@ -0,0 +1,54 @@
+package audio
+
+import (
+	"context"
+	"syscall"
+	"unsafe"
+)
+
+var playSound = syscall.NewLazyDLL("winmm.dll").NewProc("PlaySoundW")
+
+const (
+	sndSync      = 0x0000
+	sndNoDefault = 0x0002
+	sndFilename  = 0x20000
+)
+
+// winmm plays WAV files through the Windows multimedia API, which needs
+// no external program.
+type winmm struct{}
+
+func detectNative() (Player, bool) {
+	if err := playSound.Find(); err != nil {
+		return nil, false
+	}
+	return winmm{}, true
+}
+
+func (winmm) Name() string { return "winmm" }
+
+func (winmm) Play(ctx context.Context, path string) error {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		r, _, err := playSound.Call(uintptr(unsafe.Pointer(name)), 0, sndFilename|sndSync|sndNoDefault)
+		if r == 0 {
+			done <- err
+			return
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// Playing nothing stops the sound currently playing.
+		playSound.Call(0, 0, 0)
+		return ctx.Err()
+	}
+}
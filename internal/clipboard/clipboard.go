@@ -0,0 +1,69 @@
+// Package clipboard copies text to the system clipboard.
+package clipboard
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrUnavailable is returned when there is no way to reach a clipboard.
+var ErrUnavailable = errors.New("clipboard: no clipboard tool found and OSC 52 unavailable")
+
+// tools returns the clipboard commands to try on this system, best first.
+func tools() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+
+	var cmds [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		cmds = append(cmds,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"})
+	}
+	return cmds
+}
+
+// Copy puts text on the clipboard with the first platform tool found. When
+// w is not nil it also sends an OSC 52 sequence to it, which terminals
+// forward to the local clipboard, even over SSH.
+func Copy(ctx context.Context, w io.Writer, text string) error {
+	copied := false
+	if w != nil {
+		// One write, so the sequence isn't split by the renderer's output.
+		seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+		if _, err := io.WriteString(w, seq); err != nil {
+			return err
+		}
+		copied = true
+	}
+
+	for _, tool := range tools() {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if !copied {
+		return ErrUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Package events is the bus the timer announces what happens on. Storage,
+// notifications and integrations subscribe to the events they act on, so
+// adding an integration doesn't touch the timer.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"charm/test/internal/history"
+)
+
+// Event is something that happened to the timer.
+type Event interface {
+	// Name describes the event in logs and errors.
+	Name() string
+}
+
+// SessionStarted is published when a session starts counting down, or
+// is resumed after a restart.
+type SessionStarted struct {
+	Start time.Time
+	// Phase is "work", "break", "long break" or a segment's label.
+	Phase     string
+	Intention string
+	Task      string
+}
+
+// SessionEnded is published when a session is finished or abandoned.
+type SessionEnded struct {
+	Session history.Session
+}
+
+// PhaseChanged is published when the timer moves from one phase to
+// another: "idle", "work", "break", "paused" or "done".
+type PhaseChanged struct {
+	At        time.Time
+	From, To  string
+	Remaining time.Duration
+}
+
+// PhaseEnded is published when a phase runs out. Title and Body tell the
+// user what's next, e.g. in a notification.
+type PhaseEnded struct {
+	At    time.Time
+	Title string
+	Body  string
+}
+
+// SessionReviewed is published when the review of the work session
+// started at Start is answered with "yes", "no" or "partial".
+type SessionReviewed struct {
+	Start  time.Time
+	Answer string
+}
+
+// Interrupted is published when the user names why the work session
+// started at Start was paused.
+type Interrupted struct {
+	At     time.Time
+	Start  time.Time
+	Reason string
+}
+
+// GoalReached is published when the daily goal of pomodoros is reached.
+type GoalReached struct {
+	At   time.Time
+	Goal int
+}
+
+func (SessionStarted) Name() string  { return "session start" }
+func (SessionEnded) Name() string    { return "session end" }
+func (SessionReviewed) Name() string { return "review" }
+func (PhaseChanged) Name() string    { return "phase change" }
+func (PhaseEnded) Name() string      { return "phase end" }
+func (Interrupted) Name() string     { return "interruption" }
+func (GoalReached) Name() string     { return "goal" }
+
+type subscriber struct {
+	name    string
+	handler func(context.Context, Event) error
+}
+
+// Bus hands events to the subscribers of their type. Subscribers are
+// added up front; events can be published from any goroutine.
+type Bus struct {
+	subscribers map[reflect.Type][]subscriber
+	all         []subscriber
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subscribers: map[reflect.Type][]subscriber{}}
+}
+
+// Subscribe calls handler with every event of type E published on b. The
+// name tells the subscriber's errors apart.
+func Subscribe[E Event](b *Bus, name string, handler func(context.Context, E) error) {
+	t := reflect.TypeFor[E]()
+	b.subscribers[t] = append(b.subscribers[t], subscriber{name, func(ctx context.Context, e Event) error {
+		return handler(ctx, e.(E))
+	}})
+}
+
+// SubscribeAll calls handler with every event published on b, before the
+// subscribers of its type.
+func (b *Bus) SubscribeAll(name string, handler func(context.Context, Event) error) {
+	b.all = append(b.all, subscriber{name, handler})
+}
+
+// Publish hands e to its subscribers one after another, in the order they
+// subscribed, so later ones can rely on what earlier ones did. A failing
+// subscriber doesn't keep the others from running.
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	if b == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, s := range slices.Concat(b.all, b.subscribers[reflect.TypeOf(e)]) {
+		if err := s.handler(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,206 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// recorder remembers the events handed to its subscribers, by subscriber.
+type recorder struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, s)
+}
+
+func (r *recorder) all() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.got)
+}
+
+func TestPublishByType(t *testing.T) {
+	var r recorder
+	b := NewBus()
+	Subscribe(b, "started", func(_ context.Context, e SessionStarted) error {
+		r.add("started " + e.Phase)
+		return nil
+	})
+	Subscribe(b, "changed", func(_ context.Context, e PhaseChanged) error {
+		r.add("changed " + e.To)
+		return nil
+	})
+	Subscribe(b, "started again", func(_ context.Context, e SessionStarted) error {
+		r.add("started again " + e.Phase)
+		return nil
+	})
+	b.SubscribeAll("all", func(_ context.Context, e Event) error {
+		r.add("all " + e.Name())
+		return nil
+	})
+
+	for _, e := range []Event{
+		SessionStarted{Phase: "work"},
+		PhaseChanged{To: "break"},
+		GoalReached{Goal: 8},
+	} {
+		if err := b.Publish(context.Background(), e); err != nil {
+			t.Fatalf("Publish(%T) = %v", e, err)
+		}
+	}
+
+	want := []string{
+		"all session start", "started work", "started again work",
+		"all phase change", "changed break",
+		"all goal",
+	}
+	if got := r.all(); !slices.Equal(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+}
+
+func TestPublishErrors(t *testing.T) {
+	errFirst, errLast := errors.New("first failed"), errors.New("last failed")
+	var ran []string
+	b := NewBus()
+	Subscribe(b, "first", func(context.Context, GoalReached) error {
+		ran = append(ran, "first")
+		return errFirst
+	})
+	Subscribe(b, "middle", func(context.Context, GoalReached) error {
+		ran = append(ran, "middle")
+		return nil
+	})
+	Subscribe(b, "last", func(context.Context, GoalReached) error {
+		ran = append(ran, "last")
+		return errLast
+	})
+
+	err := b.Publish(context.Background(), GoalReached{})
+	if !errors.Is(err, errFirst) || !errors.Is(err, errLast) {
+		t.Errorf("Publish() = %v, want both errors", err)
+	}
+	for _, name := range []string{"first: ", "last: "} {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("Publish() = %v, missing %q", err, name)
+		}
+	}
+	if want := []string{"first", "middle", "last"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+
+	if err := (*Bus)(nil).Publish(context.Background(), GoalReached{}); err != nil {
+		t.Errorf("Publish() on a nil bus = %v", err)
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	var r recorder
+	b := NewBus()
+	Subscribe(b, "goals", func(_ context.Context, e GoalReached) error {
+		// A slow subscriber mustn't let later events overtake.
+		if e.Goal%10 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		r.add(string(rune('a' + e.Goal%26)))
+		return nil
+	})
+	q := NewQueue(b, time.Second)
+
+	var want []string
+	var results []<-chan error
+	for i := range 100 {
+		want = append(want, string(rune('a'+i%26)))
+		results = append(results, q.Add(GoalReached{Goal: i}))
+	}
+	for _, result := range results {
+		if err := <-result; err != nil {
+			t.Errorf("Add() = %v", err)
+		}
+	}
+	if got := r.all(); !slices.Equal(got, want) {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+	if err := q.Close(context.Background()); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestQueueCloseDrains(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	var r recorder
+	b := NewBus()
+	b.SubscribeAll("all", func(context.Context, Event) error {
+		time.Sleep(time.Millisecond * 5)
+		r.add("handled")
+		return nil
+	})
+	q := NewQueue(b, time.Second)
+	var results []<-chan error
+	for range 5 {
+		results = append(results, q.Add(GoalReached{}))
+	}
+
+	if err := q.Close(context.Background()); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	if got := len(r.all()); got != 5 {
+		t.Errorf("Close() returned after %d of 5 events", got)
+	}
+	for _, result := range results {
+		if err := <-result; err != nil {
+			t.Errorf("Add() = %v", err)
+		}
+	}
+	if err := <-q.Add(GoalReached{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Add() after Close() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestQueueCloseCancels(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	started := make(chan struct{})
+	var calls int
+	b := NewBus()
+	b.SubscribeAll("hanging", func(ctx context.Context, _ Event) error {
+		calls++
+		if calls == 1 {
+			close(started)
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q := NewQueue(b, time.Hour)
+	first, second := q.Add(GoalReached{}), q.Add(GoalReached{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	if err := q.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("event being published = %v, want %v", err, context.Canceled)
+	}
+	if err := <-second; !errors.Is(err, context.Canceled) {
+		t.Errorf("queued event = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("subscriber called %d times, want once", calls)
+	}
+}
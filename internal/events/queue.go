@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Queue publishes events on a bus one after another from a goroutine of its
+// own, so subscribers see them in the order they were queued, without the
+// caller waiting for them.
+type Queue struct {
+	bus     *Bus
+	timeout time.Duration
+	// ctx is cancelled once Close gives up on the queued events.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending []queued
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+type queued struct {
+	event  Event
+	result chan<- error
+}
+
+// NewQueue returns a queue publishing on bus, giving the subscribers of
+// every event timeout to handle it.
+func NewQueue(bus *Bus, timeout time.Duration) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		bus:     bus,
+		timeout: timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Add queues e and returns a channel receiving the subscribers' errors once
+// it's published. Events added after Close are dropped.
+func (q *Queue) Add(e Event) <-chan error {
+	result := make(chan error, 1)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		result <- context.Canceled
+		return result
+	}
+	q.pending = append(q.pending, queued{e, result})
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return result
+}
+
+// Close publishes the events still queued and waits until they're done.
+// Once ctx is done, it cancels the event being published, drops the rest
+// and returns ctx's error.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	defer q.cancel()
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			closed := q.closed
+			q.mu.Unlock()
+			if closed {
+				return
+			}
+			<-q.wake
+			continue
+		}
+		next := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		if err := q.ctx.Err(); err != nil {
+			next.result <- err
+			continue
+		}
+		ctx, cancel := context.WithTimeout(q.ctx, q.timeout)
+		next.result <- q.bus.Publish(ctx, next.event)
+		cancel()
+	}
+}
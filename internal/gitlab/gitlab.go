@@ -0,0 +1,197 @@
+// Package gitlab uses open GitLab issues as tasks over GitLab's REST API.
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"charm/test/internal/httpclient"
+)
+
+// Issues are the open issues of a project, or the ones assigned to the
+// owner of the token across projects. An issue's task name is its
+// reference and title, like "#42 Fix login" or "group/app#42 Fix login".
+type Issues struct {
+	base    string
+	token   string
+	oauth   func(context.Context) (string, error)
+	project string
+	client  *http.Client
+
+	mu     sync.Mutex
+	issues map[string]issue // by name
+}
+
+type issue struct {
+	IID        int    `json:"iid"`
+	ProjectID  int    `json:"project_id"`
+	Title      string `json:"title"`
+	References struct {
+		Short string `json:"short"`
+		Full  string `json:"full"`
+	} `json:"references"`
+}
+
+// New returns the issues of the GitLab instance at base, like
+// https://gitlab.com, authenticating with a personal access token. An
+// empty project lists the issues assigned to the token's owner instead.
+func New(base, token, project string) *Issues {
+	return &Issues{
+		base:    strings.TrimSuffix(base, "/") + "/api/v4",
+		token:   token,
+		project: project,
+		client:  httpclient.New(time.Second * 10),
+		issues:  map[string]issue{},
+	}
+}
+
+// NewOAuth is like New but authenticates with the OAuth access tokens
+// token returns.
+func NewOAuth(base string, token func(context.Context) (string, error), project string) *Issues {
+	is := New(base, "", project)
+	is.oauth = token
+	return is
+}
+
+// do calls the API and decodes the answer into v, unless v is nil.
+func (is *Issues) do(ctx context.Context, method, path string, params url.Values, v any) error {
+	u := is.base + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	if is.oauth != nil {
+		token, err := is.oauth(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("PRIVATE-TOKEN", is.token)
+	}
+
+	resp, err := is.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("gitlab: %s %s: %s", method, path, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// List returns the names of the open issues, most recently updated first.
+func (is *Issues) List(ctx context.Context) ([]string, error) {
+	path := "/issues"
+	params := url.Values{"state": {"opened"}, "order_by": {"updated_at"}, "per_page": {"100"}}
+	if is.project != "" {
+		path = "/projects/" + url.PathEscape(is.project) + "/issues"
+	} else {
+		params.Set("scope", "assigned_to_me")
+	}
+
+	var issues []issue
+	if err := is.do(ctx, http.MethodGet, path, params, &issues); err != nil {
+		return nil, err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	names := make([]string, len(issues))
+	is.issues = make(map[string]issue, len(issues))
+	for i, iss := range issues {
+		ref := iss.References.Full
+		if is.project != "" {
+			ref = iss.References.Short
+		}
+		names[i] = ref + " " + iss.Title
+		is.issues[names[i]] = iss
+	}
+	return names, nil
+}
+
+// Add opens an issue in the project.
+func (is *Issues) Add(ctx context.Context, name string) error {
+	if is.project == "" {
+		return errors.New("gitlab: adding issues needs a project")
+	}
+
+	var iss issue
+	path := "/projects/" + url.PathEscape(is.project) + "/issues"
+	if err := is.do(ctx, http.MethodPost, path, url.Values{"title": {name}}, &iss); err != nil {
+		return err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	is.issues[iss.References.Short+" "+iss.Title] = iss
+	return nil
+}
+
+// Complete closes the named issue.
+func (is *Issues) Complete(ctx context.Context, name string) error {
+	iss, err := is.issue(ctx, name)
+	if err != nil {
+		return err
+	}
+	params := url.Values{"state_event": {"close"}}
+	if err := is.do(ctx, http.MethodPut, iss.path(), params, nil); err != nil {
+		return err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	delete(is.issues, name)
+	return nil
+}
+
+// Annotate adds a comment to the named issue.
+func (is *Issues) Annotate(ctx context.Context, name, text string) error {
+	iss, err := is.issue(ctx, name)
+	if err != nil {
+		return err
+	}
+	return is.do(ctx, http.MethodPost, iss.path()+"/notes", url.Values{"body": {text}}, nil)
+}
+
+func (iss issue) path() string {
+	return "/projects/" + strconv.Itoa(iss.ProjectID) + "/issues/" + strconv.Itoa(iss.IID)
+}
+
+// issue returns the named issue, reloading the issues if it's not among
+// the last ones listed.
+func (is *Issues) issue(ctx context.Context, name string) (issue, error) {
+	is.mu.Lock()
+	iss, ok := is.issues[name]
+	is.mu.Unlock()
+	if ok {
+		return iss, nil
+	}
+
+	if _, err := is.List(ctx); err != nil {
+		return issue{}, err
+	}
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if iss, ok := is.issues[name]; ok {
+		return iss, nil
+	}
+	return issue{}, fmt.Errorf("gitlab: no open issue %q", name)
+}
@@ -0,0 +1,250 @@
+// Package history stores finished and aborted sessions in SQLite.
+package history
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Session is a single work session or break.
+type Session struct {
+	ID    int64
+	Start time.Time
+	End   time.Time
+	// Phase is "work", "break" or "long break".
+	Phase string
+	// Duration is the time actually spent, excluding pauses.
+	Duration time.Duration
+	// Completed is false for sessions that were reset, skipped or quit.
+	Completed bool
+	Intention string
+	// Task is the task from the task list worked on, if any.
+	Task string
+	// Interrupted is true for sessions cut short by a crash, recovered
+	// from the journal on the next start. Their end and duration are
+	// unknown.
+	Interrupted bool
+	// Review is the answer to whether the planned work got done: "yes",
+	// "no" or "partial", or empty when it wasn't asked or answered.
+	Review string
+}
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Store is the session history database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it and applying pending
+// migrations as needed.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; sharing one connection avoids
+	// SQLITE_BUSY between background jobs.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// migrate applies the embedded migrations newer than the schema version
+// recorded in the database. Migration files are numbered from 1 and each
+// runs in its own transaction together with the version bump.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	for i, f := range files[min(version, len(files)):] {
+		script, err := migrations.ReadFile("migrations/" + f.Name())
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(script)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add records a session.
+func (s *Store) Add(ctx context.Context, session Session) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO sessions (start, end, phase, duration, completed, intention, task, interrupted, review)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.Start.Unix(), session.End.Unix(), session.Phase,
+		int64(session.Duration/time.Second), session.Completed, session.Intention, session.Task,
+		session.Interrupted, session.Review)
+	return err
+}
+
+// SetReview records the review answer of the work session started at
+// start.
+func (s *Store) SetReview(ctx context.Context, start time.Time, answer string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET review = ? WHERE start = ? AND phase = 'work'`,
+		answer, start.Unix())
+	return err
+}
+
+// Delete removes the session started at start.
+func (s *Store) Delete(ctx context.Context, start time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE start = ?`, start.Unix())
+	return err
+}
+
+// Between returns the sessions started in [from, to), oldest first.
+func (s *Store) Between(ctx context.Context, from, to time.Time) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, start, end, phase, duration, completed, intention, task, interrupted, review
+		FROM sessions WHERE start >= ? AND start < ? ORDER BY start`,
+		from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var (
+			session    Session
+			start, end int64
+			duration   int64
+		)
+		if err := rows.Scan(&session.ID, &start, &end, &session.Phase,
+			&duration, &session.Completed, &session.Intention, &session.Task,
+			&session.Interrupted, &session.Review); err != nil {
+			return nil, err
+		}
+		session.Start = time.Unix(start, 0)
+		session.End = time.Unix(end, 0)
+		session.Duration = time.Duration(duration) * time.Second
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// AddInterruption records the reason a work session started at start was
+// paused at at.
+func (s *Store) AddInterruption(ctx context.Context, at, start time.Time, reason string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO interruptions (at, session_start, reason) VALUES (?, ?, ?)`,
+		at.Unix(), start.Unix(), reason)
+	return err
+}
+
+// Interruptions counts the reasons of the pauses in [from, to).
+func (s *Store) Interruptions(ctx context.Context, from, to time.Time) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT reason, COUNT(*) FROM interruptions WHERE at >= ? AND at < ? GROUP BY reason`,
+		from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			reason string
+			n      int
+		)
+		if err := rows.Scan(&reason, &n); err != nil {
+			return nil, err
+		}
+		counts[reason] = n
+	}
+
+	return counts, rows.Err()
+}
+
+// Pomodoros counts the completed work sessions spent on task.
+func (s *Store) Pomodoros(ctx context.Context, task string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sessions WHERE task = ? AND phase = 'work' AND completed`,
+		task).Scan(&n)
+	return n, err
+}
+
+// Tasks returns the names of the task list, oldest first.
+func (s *Store) Tasks(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM tasks ORDER BY created, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, name)
+	}
+
+	return tasks, rows.Err()
+}
+
+// AddTask adds a task to the task list. Adding an existing task is a no-op.
+func (s *Store) AddTask(ctx context.Context, name string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO tasks (name, created) VALUES (?, ?)`,
+		name, time.Now().Unix())
+	return err
+}
+
+// RemoveTask removes a task from the task list. Sessions keep its name.
+func (s *Store) RemoveTask(ctx context.Context, name string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM tasks WHERE name = ?`, name)
+	return err
+}
@@ -0,0 +1,202 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// latest is the schema version after all migrations.
+func latest(t *testing.T) int {
+	t.Helper()
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+func version(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var v int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func open(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestMigrateEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s := open(t, path)
+	if got, want := version(t, s.db), latest(t); got != want {
+		t.Errorf("user_version = %d, want %d", got, want)
+	}
+	s.Close()
+
+	// Opening a migrated database again leaves it as it is.
+	s = open(t, path)
+	if got, want := version(t, s.db), latest(t); got != want {
+		t.Errorf("user_version after reopening = %d, want %d", got, want)
+	}
+}
+
+func TestMigrateVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := migrations.ReadFile("migrations/001_sessions.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1_700_000_000, 0)
+	for _, q := range []string{
+		string(script),
+		"PRAGMA user_version = 1",
+		`INSERT INTO sessions (start, end, phase, duration, completed, intention)
+		VALUES (1700000000, 1700001500, 'work', 1500, 1, 'write tests')`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	s := open(t, path)
+	if got, want := version(t, s.db), latest(t); got != want {
+		t.Errorf("user_version = %d, want %d", got, want)
+	}
+	got, err := s.Between(context.Background(), start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Session{{
+		ID:        1,
+		Start:     start,
+		End:       start.Add(25 * time.Minute),
+		Phase:     "work",
+		Duration:  25 * time.Minute,
+		Completed: true,
+		Intention: "write tests",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Between() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSessionsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := open(t, filepath.Join(t.TempDir(), "history.db"))
+
+	start := time.Unix(1_700_000_000, 0)
+	sessions := []Session{
+		{
+			Start:     start,
+			End:       start.Add(25 * time.Minute),
+			Phase:     "work",
+			Duration:  25 * time.Minute,
+			Completed: true,
+			Intention: "write tests",
+			Task:      "history",
+			Review:    "partial",
+		},
+		{
+			Start:    start.Add(25 * time.Minute),
+			End:      start.Add(30 * time.Minute),
+			Phase:    "break",
+			Duration: 5 * time.Minute,
+		},
+		{
+			Start:       start.Add(30 * time.Minute),
+			End:         start.Add(30 * time.Minute),
+			Phase:       "work",
+			Task:        "history",
+			Interrupted: true,
+		},
+	}
+	for _, session := range sessions {
+		if err := s.Add(ctx, session); err != nil {
+			t.Fatalf("Add() = %v", err)
+		}
+	}
+	for i := range sessions {
+		sessions[i].ID = int64(i + 1)
+	}
+
+	got, err := s.Between(ctx, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sessions) {
+		t.Errorf("Between() = %+v, want %+v", got, sessions)
+	}
+
+	if err := s.SetReview(ctx, start.Add(30*time.Minute), "no"); err != nil {
+		t.Fatalf("SetReview() = %v", err)
+	}
+	sessions[2].Review = "no"
+	if err := s.Delete(ctx, start.Add(25*time.Minute)); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	sessions = append(sessions[:1], sessions[2])
+
+	got, err = s.Between(ctx, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sessions) {
+		t.Errorf("Between() after SetReview() and Delete() = %+v, want %+v", got, sessions)
+	}
+
+	// The range is half-open.
+	got, err = s.Between(ctx, start.Add(time.Second), start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Between() past the first and up to the last session = %+v", got)
+	}
+}
+
+func TestInterruptions(t *testing.T) {
+	ctx := context.Background()
+	s := open(t, filepath.Join(t.TempDir(), "history.db"))
+
+	start := time.Unix(1_700_000_000, 0)
+	for i, reason := range []string{"meeting", "chat", "meeting"} {
+		at := start.Add(time.Duration(i+1) * time.Minute)
+		if err := s.AddInterruption(ctx, at, start, reason); err != nil {
+			t.Fatalf("AddInterruption() = %v", err)
+		}
+	}
+
+	got, err := s.Interruptions(ctx, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"meeting": 2, "chat": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Interruptions() = %v, want %v", got, want)
+	}
+
+	got, err = s.Interruptions(ctx, start.Add(2*time.Minute), start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"chat": 1, "meeting": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Interruptions() from the second pause = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE sessions (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	start     INTEGER NOT NULL,
+	end       INTEGER NOT NULL,
+	phase     TEXT    NOT NULL,
+	duration  INTEGER NOT NULL,
+	completed INTEGER NOT NULL,
+	intention TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX sessions_start ON sessions (start);
@@ -0,0 +1,7 @@
+ALTER TABLE sessions ADD COLUMN task TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE tasks (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	name    TEXT    NOT NULL UNIQUE,
+	created INTEGER NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE sessions ADD COLUMN interrupted INTEGER NOT NULL DEFAULT 0;
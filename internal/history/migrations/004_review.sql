@@ -0,0 +1 @@
+ALTER TABLE sessions ADD COLUMN review TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+CREATE TABLE interruptions (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	at            INTEGER NOT NULL,
+	session_start INTEGER NOT NULL,
+	reason        TEXT    NOT NULL
+);
+
+CREATE INDEX interruptions_at ON interruptions (at);
@@ -0,0 +1,185 @@
+// Package httpclient is the HTTP client the integrations share. It sets
+// the user agent, spaces out requests to each host and retries failed
+// requests with jittered backoff, so syncing a lot at once doesn't get a
+// user throttled.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UserAgent is sent with every request.
+const UserAgent = "pomodoro"
+
+const (
+	// interval is the least time between two requests to the same host.
+	interval = time.Millisecond * 100
+	// attempts is how often a request is tried before giving up.
+	attempts = 4
+	// backoff is the base delay between attempts, doubled every retry.
+	backoff = time.Millisecond * 500
+	// maxDelay caps the delay a server can ask for with Retry-After.
+	maxDelay = time.Second * 30
+)
+
+// shared is the transport of every client, so the spacing per host holds
+// across integrations.
+var shared = &transport{base: http.DefaultTransport, next: map[string]time.Time{}}
+
+// New returns a client giving up on a request, retries included, after
+// timeout.
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: shared}
+}
+
+type transport struct {
+	base http.RoundTripper
+
+	mu sync.Mutex
+	// next is the earliest time of the next request to a host.
+	next map[string]time.Time
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	req = req.Clone(ctx)
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := sleep(ctx, t.reserve(req.URL.Host)); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		delay, ok := retryDelay(req, resp, err, attempt)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				t.holdOff(req.URL.Host, delay)
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reserve returns how long to wait before the next request to host and
+// books its slot.
+func (t *transport) reserve(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	at := now
+	if next := t.next[host]; next.After(now) {
+		at = next
+	}
+	t.next[host] = at.Add(interval)
+	return at.Sub(now)
+}
+
+// holdOff keeps every request to host waiting for d, like a server asked
+// with Retry-After.
+func (t *transport) holdOff(host string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if at := time.Now().Add(d); at.After(t.next[host]) {
+		t.next[host] = at
+	}
+}
+
+// retryDelay reports whether a request is worth another attempt and how
+// long to wait for it. Requests that may have had an effect are only
+// retried when idempotent; throttled ones always are.
+func retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= attempts || (req.Body != nil && req.GetBody == nil) {
+		return 0, false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	switch {
+	case err != nil:
+		if !idempotent(req.Method) {
+			return 0, false
+		}
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusServiceUnavailable:
+		if d, ok := retryAfter(resp); ok {
+			return d, true
+		}
+	case resp.StatusCode >= 500:
+		if !idempotent(req.Method) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	// Full jitter keeps clients that failed together from retrying
+	// together.
+	return rand.N(backoff << (attempt - 1)), true
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the delay asked for in the Retry-After header, in
+// seconds or as a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return min(time.Duration(s)*time.Second, maxDelay), true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return min(max(0, time.Until(at)), maxDelay), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
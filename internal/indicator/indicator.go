@@ -0,0 +1,71 @@
+// Package indicator mirrors the timer phase on physical devices, such as
+// a focus light outside the office door.
+package indicator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Indicator shows the current phase ("work", "break", "paused", "idle"
+// or "done") on a device.
+type Indicator interface {
+	Show(ctx context.Context, phase string) error
+}
+
+// gpioRoot is the sysfs GPIO interface on Linux single-board computers.
+const gpioRoot = "/sys/class/gpio"
+
+type gpio struct {
+	pin int
+}
+
+// GPIO returns an indicator driving the pin high during work sessions and
+// low otherwise, e.g. to switch an LED.
+func GPIO(pin int) Indicator {
+	return gpio{pin}
+}
+
+func (g gpio) Show(_ context.Context, phase string) error {
+	dir := filepath.Join(gpioRoot, fmt.Sprintf("gpio%d", g.pin))
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.WriteFile(filepath.Join(gpioRoot, "export"), []byte(strconv.Itoa(g.pin)), 0); err != nil {
+			return fmt.Errorf("exporting gpio %d: %w", g.pin, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "direction"), []byte("out"), 0); err != nil {
+		return err
+	}
+
+	value := "0"
+	if phase == "work" {
+		value = "1"
+	}
+	return os.WriteFile(filepath.Join(dir, "value"), []byte(value), 0)
+}
+
+type serial struct {
+	path string
+}
+
+// Serial returns an indicator writing each phase as a line to a serial
+// device. The port speed has to be set up beforehand, e.g. with stty.
+func Serial(path string) Indicator {
+	return serial{path}
+}
+
+func (s serial) Show(_ context.Context, phase string) error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(phase + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,78 @@
+package indicator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// phaseColors tints lights red while focusing and green during breaks.
+var phaseColors = map[string][3]uint8{
+	"work":   {0xff, 0x00, 0x00},
+	"break":  {0x00, 0xff, 0x00},
+	"paused": {0xff, 0xa5, 0x00},
+	"idle":   {0xff, 0xff, 0xff},
+	"done":   {0xff, 0xff, 0xff},
+}
+
+// ledRoot holds the sysfs LED class devices.
+const ledRoot = "/sys/class/leds"
+
+type led struct {
+	name string
+}
+
+// LED returns an indicator for a sysfs LED such as a keyboard backlight.
+// Multicolor LEDs are tinted by phase, single color ones light up during
+// work sessions only.
+func LED(name string) Indicator {
+	return led{name}
+}
+
+func (l led) Show(_ context.Context, phase string) error {
+	dir := filepath.Join(ledRoot, l.name)
+
+	data, err := os.ReadFile(filepath.Join(dir, "max_brightness"))
+	if err != nil {
+		return err
+	}
+	maxBrightness := strings.TrimSpace(string(data))
+
+	if _, err := os.Stat(filepath.Join(dir, "multi_intensity")); err == nil {
+		c := phaseColors[phase]
+		intensity := fmt.Sprintf("%d %d %d", c[0], c[1], c[2])
+		if err := os.WriteFile(filepath.Join(dir, "multi_intensity"), []byte(intensity), 0); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, "brightness"), []byte(maxBrightness), 0)
+	}
+
+	brightness := "0"
+	if phase == "work" {
+		brightness = maxBrightness
+	}
+	return os.WriteFile(filepath.Join(dir, "brightness"), []byte(brightness), 0)
+}
+
+type openRGB struct {
+	device int
+}
+
+// OpenRGB returns an indicator setting a static color on an OpenRGB
+// device through the openrgb command line client.
+func OpenRGB(device int) Indicator {
+	return openRGB{device}
+}
+
+func (o openRGB) Show(ctx context.Context, phase string) error {
+	c := phaseColors[phase]
+	return exec.CommandContext(ctx, "openrgb",
+		"--device", strconv.Itoa(o.device),
+		"--mode", "static",
+		"--color", fmt.Sprintf("%02X%02X%02X", c[0], c[1], c[2]),
+	).Run()
+}
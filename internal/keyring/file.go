@@ -0,0 +1,163 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileStore keeps secrets in a file encrypted with AES-GCM. The key is a
+// random one in a file of its own, only readable by the user. Anything
+// running as the user can read the key as well, so the encryption only
+// guards against casual reading, like a glance at the file or a copy of
+// it alone; it is no match for a system keychain.
+type fileStore struct {
+	path    string
+	keyPath string
+}
+
+// key returns the encryption key, creating it the first time.
+func (f *fileStore) key(create bool) ([]byte, error) {
+	key, err := os.ReadFile(f.keyPath)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(f.keyPath), 0o700); err != nil {
+			return nil, err
+		}
+		return key, writeFile(f.keyPath, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("keyring: %s is not a key", f.keyPath)
+	}
+	return key, nil
+}
+
+func (f *fileStore) aead(create bool) (cipher.AEAD, error) {
+	key, err := f.key(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// load decrypts the stored secrets. A missing file holds none.
+func (f *fileStore) load() (map[string]string, error) {
+	secrets := map[string]string{}
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := f.aead(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("keyring: %s is damaged", f.path)
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decrypting %s: %w", f.path, err)
+	}
+	return secrets, json.Unmarshal(plain, &secrets)
+}
+
+func (f *fileStore) save(secrets map[string]string) error {
+	aead, err := f.aead(true)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	return writeFile(f.path, aead.Seal(nonce, nonce, plain, nil))
+}
+
+// writeFile replaces the file at path with data, readable by the user
+// only. It writes a temporary file first, so a crash can't leave the file
+// cut short and the secrets lost.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *fileStore) get(name string) (string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (f *fileStore) set(name, secret string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = secret
+	return f.save(secrets)
+}
+
+func (f *fileStore) delete(name string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, name)
+	return f.save(secrets)
+}
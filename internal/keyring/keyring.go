@@ -0,0 +1,199 @@
+// Package keyring keeps integration credentials in the system's keychain:
+// the macOS Keychain, the Secret Service on Linux and BSD, or the Windows
+// Credential Manager. Without one, secrets go to an encrypted file, which
+// only keeps them from casual reading.
+package keyring
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// service groups the secrets in the keychain.
+const service = "pomodoro"
+
+// ErrNotFound is returned for secrets that were never stored.
+var ErrNotFound = errors.New("keyring: secret not found")
+
+// ValidName reports whether name can name a secret: letters, digits, dots,
+// dashes and underscores.
+func ValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("._-", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// backend stores secrets by name.
+type backend interface {
+	get(name string) (string, error)
+	set(name, secret string) error
+	delete(name string) error
+}
+
+// Keyring stores secrets in the system's keychain, if there is one, and an
+// encrypted file otherwise.
+type Keyring struct {
+	native backend
+	file   *fileStore
+}
+
+// New returns a keyring falling back to the secrets file at path, which
+// is encrypted with the key in keyPath. Keeping the two apart, like in the
+// config and the data directory, keeps a copy of one from revealing the
+// secrets.
+func New(path, keyPath string) *Keyring {
+	return &Keyring{native: detect(), file: &fileStore{path: path, keyPath: keyPath}}
+}
+
+// Backend names where secrets are stored.
+func (k *Keyring) Backend() string {
+	switch k.native.(type) {
+	case macKeychain:
+		return "macOS Keychain"
+	case secretService:
+		return "Secret Service"
+	case nil:
+		return "encrypted file " + k.file.path
+	default:
+		return "Credential Manager"
+	}
+}
+
+// Get returns the named secret, looking in the file too, where it was
+// stored before a keychain was around.
+func (k *Keyring) Get(name string) (string, error) {
+	if !ValidName(name) {
+		return "", fmt.Errorf("keyring: invalid name %q", name)
+	}
+	if k.native != nil {
+		secret, err := k.native.get(name)
+		if !errors.Is(err, ErrNotFound) {
+			return secret, err
+		}
+	}
+	return k.file.get(name)
+}
+
+// Set stores the named secret.
+func (k *Keyring) Set(name, secret string) error {
+	if !ValidName(name) {
+		return fmt.Errorf("keyring: invalid name %q", name)
+	}
+	if k.native != nil {
+		return k.native.set(name, secret)
+	}
+	return k.file.set(name, secret)
+}
+
+// Delete removes the named secret from everywhere it's stored.
+func (k *Keyring) Delete(name string) error {
+	if !ValidName(name) {
+		return fmt.Errorf("keyring: invalid name %q", name)
+	}
+	var errs []error
+	if k.native != nil {
+		if err := k.native.delete(name); !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if err := k.file.delete(name); !errors.Is(err, ErrNotFound) {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// detect returns the system's keychain, or nil without one.
+func detect() backend {
+	if b := detectNative(); b != nil {
+		return b
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macKeychain{}
+		}
+	case "windows", "android", "ios":
+	default:
+		if _, err := exec.LookPath("secret-tool"); err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+			return secretService{}
+		}
+	}
+	return nil
+}
+
+// run runs a keychain tool, feeding it stdin, and returns its output.
+func run(stdin string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	return strings.TrimSuffix(string(out), "\n"), err
+}
+
+// macKeychain uses the security tool of macOS.
+type macKeychain struct{}
+
+func (macKeychain) get(name string) (string, error) {
+	secret, err := run("", "security", "find-generic-password", "-s", service, "-a", name, "-w")
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 44 {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func (macKeychain) set(name, secret string) error {
+	// Commands read in interactive mode keep the secret out of the
+	// arguments, which other users could see.
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", service, name, hex.EncodeToString([]byte(secret)))
+	_, err := run(command, "security", "-i")
+	return err
+}
+
+func (macKeychain) delete(name string) error {
+	_, err := run("", "security", "delete-generic-password", "-s", service, "-a", name)
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 44 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// secretService uses libsecret's secret-tool, which talks to GNOME
+// Keyring, KWallet or KeePassXC.
+type secretService struct{}
+
+func (secretService) get(name string) (string, error) {
+	secret, err := run("", "secret-tool", "lookup", "service", service, "account", name)
+	var exit *exec.ExitError
+	if errors.As(err, &exit) || (err == nil && secret == "") {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func (secretService) set(name, secret string) error {
+	_, err := run(secret, "secret-tool", "store", "--label", service+" "+name, "service", service, "account", name)
+	return err
+}
+
+func (secretService) delete(name string) error {
+	_, err := run("", "secret-tool", "clear", "service", service, "account", name)
+	return err
+}
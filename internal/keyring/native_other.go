@@ -0,0 +1,7 @@
+//go:build !windows
+
+package keyring
+
+func detectNative() backend {
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package linear uses the open Linear issues assigned to the user as tasks
+// over Linear's GraphQL API.
+package linear
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"charm/test/internal/httpclient"
+)
+
+const api = "https://api.linear.app/graphql"
+
+// Issues are the open issues assigned to the owner of an API key. An
+// issue's task name is its identifier and title, like "ENG-42 Fix login".
+type Issues struct {
+	key    string
+	client *http.Client
+
+	mu     sync.Mutex
+	issues map[string]string // name to issue ID
+}
+
+// New returns the issues assigned to the owner of a personal API key.
+func New(key string) *Issues {
+	return &Issues{
+		key:    key,
+		client: httpclient.New(time.Second * 10),
+		issues: map[string]string{},
+	}
+}
+
+// query runs a GraphQL query and decodes its data into v, unless v is nil.
+func (is *Issues) query(ctx context.Context, query string, vars map[string]any, v any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", is.key)
+
+	resp, err := is.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("linear: %s", resp.Status)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return errors.New("linear: " + result.Errors[0].Message)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Data, v)
+}
+
+const listQuery = `query {
+	viewer {
+		assignedIssues(filter: {state: {type: {nin: ["completed", "canceled"]}}}, orderBy: updatedAt) {
+			nodes { id identifier title }
+		}
+	}
+}`
+
+// List returns the names of the open issues.
+func (is *Issues) List(ctx context.Context) ([]string, error) {
+	var data struct {
+		Viewer struct {
+			AssignedIssues struct {
+				Nodes []struct {
+					ID         string `json:"id"`
+					Identifier string `json:"identifier"`
+					Title      string `json:"title"`
+				} `json:"nodes"`
+			} `json:"assignedIssues"`
+		} `json:"viewer"`
+	}
+	if err := is.query(ctx, listQuery, nil, &data); err != nil {
+		return nil, err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	nodes := data.Viewer.AssignedIssues.Nodes
+	names := make([]string, len(nodes))
+	is.issues = make(map[string]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = n.Identifier + " " + n.Title
+		is.issues[names[i]] = n.ID
+	}
+	return names, nil
+}
+
+const doneStatesQuery = `query($id: String!) {
+	issue(id: $id) {
+		team { states(filter: {type: {eq: "completed"}}) { nodes { id } } }
+	}
+}`
+
+const updateMutation = `mutation($id: String!, $state: String!) {
+	issueUpdate(id: $id, input: {stateId: $state}) { success }
+}`
+
+// Complete moves the named issue to its team's first completed state.
+func (is *Issues) Complete(ctx context.Context, name string) error {
+	id, err := is.issue(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var data struct {
+		Issue struct {
+			Team struct {
+				States struct {
+					Nodes []struct {
+						ID string `json:"id"`
+					} `json:"nodes"`
+				} `json:"states"`
+			} `json:"team"`
+		} `json:"issue"`
+	}
+	if err := is.query(ctx, doneStatesQuery, map[string]any{"id": id}, &data); err != nil {
+		return err
+	}
+	states := data.Issue.Team.States.Nodes
+	if len(states) == 0 {
+		return fmt.Errorf("linear: the team of %q has no completed state", name)
+	}
+	if err := is.query(ctx, updateMutation, map[string]any{"id": id, "state": states[0].ID}, nil); err != nil {
+		return err
+	}
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	delete(is.issues, name)
+	return nil
+}
+
+const commentMutation = `mutation($id: String!, $body: String!) {
+	commentCreate(input: {issueId: $id, body: $body}) { success }
+}`
+
+// Annotate comments on the named issue.
+func (is *Issues) Annotate(ctx context.Context, name, text string) error {
+	id, err := is.issue(ctx, name)
+	if err != nil {
+		return err
+	}
+	return is.query(ctx, commentMutation, map[string]any{"id": id, "body": text}, nil)
+}
+
+// issue returns the ID of the named issue, reloading the issues if it's
+// not among the last ones listed.
+func (is *Issues) issue(ctx context.Context, name string) (string, error) {
+	is.mu.Lock()
+	id, ok := is.issues[name]
+	is.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	if _, err := is.List(ctx); err != nil {
+		return "", err
+	}
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if id, ok := is.issues[name]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("linear: no open issue %q", name)
+}
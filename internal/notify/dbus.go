@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type dbusNotifier struct {
+	app string
+}
+
+// DBus returns a notifier talking to the freedesktop notification
+// service on the session bus.
+func DBus(app string) Notifier {
+	return dbusNotifier{app}
+}
+
+func (n dbusNotifier) Name() string { return "dbus" }
+
+func (n dbusNotifier) Notify(ctx context.Context, title, body string) error {
+	conn, err := dbus.SessionBusPrivate(dbus.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Auth(nil); err != nil {
+		return err
+	}
+	if err := conn.Hello(); err != nil {
+		return err
+	}
+
+	obj := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	return obj.CallWithContext(ctx, "org.freedesktop.Notifications.Notify", 0,
+		n.app, uint32(0), "", title, body, []string{}, map[string]dbus.Variant{}, int32(-1)).Err
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type osascript struct{}
+
+// OSAScript returns a notifier using AppleScript's display notification.
+func OSAScript() Notifier {
+	return osascript{}
+}
+
+func (osascript) Name() string { return "osascript" }
+
+func (osascript) Notify(ctx context.Context, title, body string) error {
+	return exec.CommandContext(ctx, "osascript",
+		"-e", "on run argv",
+		"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+		"-e", "end run",
+		title, body).Run()
+}
+
+// toastScript shows a toast through the WinRT notification API. Title and
+// body come in through the environment to avoid quoting issues.
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:POMODORO_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:POMODORO_BODY)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($env:POMODORO_APP).Show($toast)
+`
+
+type toast struct {
+	app string
+}
+
+// Toast returns a notifier showing Windows toast notifications via
+// PowerShell.
+func Toast(app string) Notifier {
+	return toast{app}
+}
+
+func (toast) Name() string { return "toast" }
+
+func (t toast) Notify(ctx context.Context, title, body string) error {
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = append(os.Environ(),
+		"POMODORO_APP="+t.app,
+		"POMODORO_TITLE="+title,
+		"POMODORO_BODY="+body,
+	)
+	return cmd.Run()
+}
+
+type command struct {
+	name string
+	args []string
+}
+
+// Command returns a notifier running an arbitrary command. The {title}
+// and {body} placeholders in args are replaced before each run.
+func Command(name string, args ...string) Notifier {
+	return command{name, args}
+}
+
+func (command) Name() string { return "command" }
+
+func (c command) Notify(ctx context.Context, title, body string) error {
+	r := strings.NewReplacer("{title}", title, "{body}", body)
+
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = r.Replace(arg)
+	}
+	return exec.CommandContext(ctx, c.name, args...).Run()
+}
@@ -0,0 +1,71 @@
+// Package notify shows desktop notifications through the mechanism native
+// to the host system.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Notifier shows a single notification.
+type Notifier interface {
+	// Name identifies the backend, e.g. "dbus" or "osascript".
+	Name() string
+	Notify(ctx context.Context, title, body string) error
+}
+
+// Backends lists the backend names accepted by ByName.
+var Backends = []string{"dbus", "osascript", "toast", "terminal", "command"}
+
+// Detect returns the notifier native to this system.
+func Detect() (Notifier, error) {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return nil, fmt.Errorf("no D-Bus session bus found")
+		}
+		return DBus("pomodoro"), nil
+	case "darwin":
+		if _, err := exec.LookPath("osascript"); err != nil {
+			return nil, err
+		}
+		return OSAScript(), nil
+	case "windows":
+		if _, err := exec.LookPath("powershell"); err != nil {
+			return nil, err
+		}
+		return Toast("Pomodoro"), nil
+	default:
+		return nil, fmt.Errorf("no notification backend for %s", runtime.GOOS)
+	}
+}
+
+// ByName returns the named backend. The command backend runs command,
+// which may contain {title} and {body} placeholders.
+func ByName(name, command string) (Notifier, error) {
+	switch name {
+	case "":
+		return Detect()
+	case "dbus":
+		return DBus("pomodoro"), nil
+	case "osascript":
+		return OSAScript(), nil
+	case "toast":
+		return Toast("Pomodoro"), nil
+	case "terminal":
+		return Terminal(os.Stdout, true), nil
+	case "command":
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("the command backend needs a command")
+		}
+		return Command(args[0], args[1:]...), nil
+	default:
+		return nil, fmt.Errorf("unknown notification backend %q, expected one of %s",
+			name, strings.Join(Backends, ", "))
+	}
+}
@@ -0,0 +1,22 @@
+package notify
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+// Quiet reports whether a Focus mode such as Do Not Disturb or the
+// screen-sharing focus is active, and why.
+func Quiet() (reason string, quiet bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, "Library", "DoNotDisturb", "DB", "Assertions.json"))
+	if err != nil || !bytes.Contains(data, []byte("storeAssertionRecords")) {
+		return "", false
+	}
+	return "a Focus mode is on", true
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Quiet reports whether the desktop asked not to be disturbed, and why.
+// On Linux this is GNOME's or KDE's do-not-disturb switch, or an app
+// keeping the session from going idle the way presentations, full-screen
+// video and screen sharing do.
+func Quiet() (reason string, quiet bool) {
+	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.notifications", "show-banners").Output()
+	if err == nil && bytes.Equal(bytes.TrimSpace(out), []byte("false")) {
+		return "do not disturb is on", true
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+
+	// KDE's do-not-disturb switch.
+	var (
+		prop      dbus.Variant
+		inhibited bool
+	)
+	notifications := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	if notifications.CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0,
+		"org.freedesktop.Notifications", "Inhibited").Store(&prop) == nil && prop.Store(&inhibited) == nil && inhibited {
+		return "do not disturb is on", true
+	}
+
+	// GNOME, with the idle flag of org.gnome.SessionManager.Inhibit.
+	const inhibitIdle = 8
+	session := conn.Object("org.gnome.SessionManager", "/org/gnome/SessionManager")
+	if session.CallWithContext(ctx, "org.gnome.SessionManager.IsInhibited", 0, uint32(inhibitIdle)).Store(&inhibited) == nil && inhibited {
+		return "an app is presenting or sharing the screen", true
+	}
+	// KDE and others.
+	power := conn.Object("org.freedesktop.PowerManagement.Inhibit", "/org/freedesktop/PowerManagement/Inhibit")
+	if power.CallWithContext(ctx, "org.freedesktop.PowerManagement.Inhibit.HasInhibit", 0).Store(&inhibited) == nil && inhibited {
+		return "an app is presenting or sharing the screen", true
+	}
+	return "", false
+}
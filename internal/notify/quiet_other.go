@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package notify
+
+// Quiet reports whether the user asked not to be disturbed, which can't
+// be detected on this system.
+func Quiet() (reason string, quiet bool) {
+	return "", false
+}
@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var queryUserNotificationState = syscall.NewLazyDLL("shell32.dll").NewProc("SHQueryUserNotificationState")
+
+// Values of QUERY_USER_NOTIFICATION_STATE that mean the user is busy.
+const (
+	qunsBusy               = 2
+	qunsRunningD3DFullScrn = 3
+	qunsPresentationMode   = 4
+	qunsQuietTime          = 6
+)
+
+// Quiet reports whether the user is presenting, running a full-screen
+// application or has quiet hours on, and why.
+func Quiet() (reason string, quiet bool) {
+	var state int32
+	if r, _, _ := queryUserNotificationState.Call(uintptr(unsafe.Pointer(&state))); r != 0 {
+		return "", false
+	}
+
+	switch state {
+	case qunsBusy, qunsRunningD3DFullScrn:
+		return "a full-screen app is running", true
+	case qunsPresentationMode:
+		return "presentation mode is on", true
+	case qunsQuietTime:
+		return "quiet hours are on", true
+	default:
+		return "", false
+	}
+}
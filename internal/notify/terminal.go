@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+type terminal struct {
+	w   io.Writer
+	osc bool
+}
+
+// Terminal returns a notifier ringing the bell on w and, when osc is set,
+// sending an OSC 9 notification that terminals like iTerm2, kitty and
+// foot turn into a native alert. It works over SSH where desktop
+// notifications can't reach the user.
+func Terminal(w io.Writer, osc bool) Notifier {
+	return terminal{w, osc}
+}
+
+func (terminal) Name() string { return "terminal" }
+
+func (t terminal) Notify(_ context.Context, title, body string) error {
+	seq := "\a"
+	if t.osc {
+		// Control characters would end the sequence early.
+		msg := strings.Map(func(r rune) rune {
+			if r < 0x20 || r == 0x7f {
+				return ' '
+			}
+			return r
+		}, title+": "+body)
+		seq = "\x1b]9;" + msg + "\a"
+	}
+
+	// One write, so the sequence isn't split by the renderer's output.
+	_, err := io.WriteString(t.w, seq)
+	return err
+}
@@ -0,0 +1,270 @@
+// Package oauth logs in to services with the OAuth 2.0 device
+// authorization grant (RFC 8628): the user approves the login in a browser,
+// on any device, while the CLI waits for the token. Tokens are refreshed
+// when they expire and can be revoked (RFC 7009).
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"charm/test/internal/httpclient"
+)
+
+// Config is an OAuth client of a service.
+type Config struct {
+	DeviceURL string
+	TokenURL  string
+	// RevokeURL is empty for services that can't revoke tokens.
+	RevokeURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// Token is what a login grants.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// expired reports whether the token is about to expire. Tokens without an
+// expiry don't.
+func (t Token) expired(now time.Time) bool {
+	return !t.Expiry.IsZero() && now.Add(time.Minute).After(t.Expiry)
+}
+
+// Device is a pending login the user has to approve.
+type Device struct {
+	// URL is where the user enters Code. CompleteURL, if the service
+	// gives one, has the code filled in.
+	URL         string
+	CompleteURL string
+	Code        string
+	Expiry      time.Time
+
+	deviceCode string
+	interval   time.Duration
+}
+
+// Error is an error the service answered with, like "access_denied".
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return "oauth: " + e.Code + ": " + e.Description
+	}
+	return "oauth: " + e.Code
+}
+
+var client = httpclient.New(time.Second * 30)
+
+// post sends a form and decodes the JSON answer into v, unless v is nil.
+func (c Config) post(ctx context.Context, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var e Error
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Code != "" {
+			return &e
+		}
+		return fmt.Errorf("oauth: %s: %s", endpoint, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c Config) form(v url.Values) url.Values {
+	v.Set("client_id", c.ClientID)
+	if c.ClientSecret != "" {
+		v.Set("client_secret", c.ClientSecret)
+	}
+	return v
+}
+
+// Start begins a login.
+func (c Config) Start(ctx context.Context) (*Device, error) {
+	var resp struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		// Google names it verification_url.
+		VerificationURL         string `json:"verification_url"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	form := c.form(url.Values{"scope": {strings.Join(c.Scopes, " ")}})
+	if err := c.post(ctx, c.DeviceURL, form, &resp); err != nil {
+		return nil, err
+	}
+
+	d := &Device{
+		URL:         resp.VerificationURI,
+		CompleteURL: resp.VerificationURIComplete,
+		Code:        resp.UserCode,
+		Expiry:      time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+		deviceCode:  resp.DeviceCode,
+		interval:    time.Duration(resp.Interval) * time.Second,
+	}
+	if d.URL == "" {
+		d.URL = resp.VerificationURL
+	}
+	if d.interval <= 0 {
+		d.interval = time.Second * 5
+	}
+	return d, nil
+}
+
+// Wait polls for the token until the user approved or denied the login,
+// or it expired.
+func (c Config) Wait(ctx context.Context, d *Device) (Token, error) {
+	ctx, cancel := context.WithDeadline(ctx, d.Expiry)
+	defer cancel()
+
+	interval := d.interval
+	form := c.form(url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {d.deviceCode},
+	})
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return Token{}, errors.New("oauth: the login expired")
+			}
+			return Token{}, ctx.Err()
+		}
+
+		tok, err := c.token(ctx, form)
+		var e *Error
+		switch {
+		case errors.As(err, &e) && e.Code == "authorization_pending":
+		case errors.As(err, &e) && e.Code == "slow_down":
+			interval += time.Second * 5
+		default:
+			return tok, err
+		}
+	}
+}
+
+// token asks the token endpoint for a token.
+func (c Config) token(ctx context.Context, form url.Values) (Token, error) {
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := c.post(ctx, c.TokenURL, form, &resp); err != nil {
+		return Token{}, err
+	}
+	if resp.AccessToken == "" {
+		return Token{}, errors.New("oauth: no access token granted")
+	}
+
+	tok := Token{AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken}
+	if resp.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// Refresh trades the refresh token for a new access token.
+func (c Config) Refresh(ctx context.Context, tok Token) (Token, error) {
+	if tok.RefreshToken == "" {
+		return Token{}, errors.New("oauth: the token expired and can't be refreshed, log in again")
+	}
+	fresh, err := c.token(ctx, c.form(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tok.RefreshToken},
+	}))
+	if err != nil {
+		return Token{}, err
+	}
+	// Some services keep the refresh token for good and don't send it
+	// again.
+	if fresh.RefreshToken == "" {
+		fresh.RefreshToken = tok.RefreshToken
+	}
+	return fresh, nil
+}
+
+// Revoke revokes the login. Revoking the refresh token revokes the access
+// tokens granted with it too.
+func (c Config) Revoke(ctx context.Context, tok Token) error {
+	if c.RevokeURL == "" {
+		return errors.New("oauth: the service can't revoke tokens")
+	}
+	token := tok.RefreshToken
+	if token == "" {
+		token = tok.AccessToken
+	}
+	return c.post(ctx, c.RevokeURL, c.form(url.Values{"token": {token}}), nil)
+}
+
+// Source hands out access tokens, refreshing them as they expire.
+type Source struct {
+	cfg  Config
+	load func() (Token, error)
+	save func(Token) error
+
+	mu     sync.Mutex
+	tok    Token
+	loaded bool
+}
+
+// NewSource returns a source of the token load returns, which is only
+// called once a token is needed. Refreshed tokens are passed to save.
+func NewSource(cfg Config, load func() (Token, error), save func(Token) error) *Source {
+	return &Source{cfg: cfg, load: load, save: save}
+}
+
+// Token returns a valid access token.
+func (s *Source) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loaded {
+		tok, err := s.load()
+		if err != nil {
+			return "", err
+		}
+		s.tok, s.loaded = tok, true
+	}
+	if s.tok.expired(time.Now()) {
+		tok, err := s.cfg.Refresh(ctx, s.tok)
+		if err != nil {
+			return "", err
+		}
+		s.tok = tok
+		if err := s.save(tok); err != nil {
+			return "", err
+		}
+	}
+	return s.tok.AccessToken, nil
+}
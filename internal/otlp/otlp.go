@@ -0,0 +1,111 @@
+// Package otlp exports events as OTLP log records over HTTP/JSON, enough
+// to feed a local OpenTelemetry collector without pulling in the SDK.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm/test/internal/httpclient"
+)
+
+// Exporter sends log records to a collector's /v1/logs endpoint.
+type Exporter struct {
+	endpoint string
+	service  string
+	client   *http.Client
+}
+
+// New returns an exporter for the collector at endpoint, such as
+// http://localhost:4318.
+func New(endpoint, service string) *Exporter {
+	return &Exporter{
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/v1/logs",
+		service:  service,
+		client:   httpclient.New(time.Second * 10),
+	}
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type logRecord struct {
+	TimeUnixNano   string     `json:"timeUnixNano"`
+	SeverityNumber int        `json:"severityNumber"`
+	SeverityText   string     `json:"severityText"`
+	Body           anyValue   `json:"body"`
+	Attributes     []keyValue `json:"attributes"`
+}
+
+type scopeLogs struct {
+	Scope      map[string]string `json:"scope"`
+	LogRecords []logRecord       `json:"logRecords"`
+}
+
+type resourceLogs struct {
+	Resource struct {
+		Attributes []keyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+// Emit sends a single event with the given attributes.
+func (e *Exporter) Emit(ctx context.Context, at time.Time, event string, attrs map[string]string) error {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	record := logRecord{
+		TimeUnixNano:   strconv.FormatInt(at.UnixNano(), 10),
+		SeverityNumber: 9, // INFO
+		SeverityText:   "INFO",
+		Body:           anyValue{event},
+	}
+	for _, name := range names {
+		record.Attributes = append(record.Attributes, keyValue{name, anyValue{attrs[name]}})
+	}
+
+	var rl resourceLogs
+	rl.Resource.Attributes = []keyValue{{"service.name", anyValue{e.service}}}
+	rl.ScopeLogs = []scopeLogs{{
+		Scope:      map[string]string{"name": e.service},
+		LogRecords: []logRecord{record},
+	}}
+
+	body, err := json.Marshal(map[string][]resourceLogs{"resourceLogs": {rl}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp: collector answered %s", resp.Status)
+	}
+	return nil
+}
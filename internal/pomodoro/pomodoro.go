@@ -0,0 +1,258 @@
+// Package pomodoro is the timer's state machine: which phase the timer is
+// in, when that phase ends and when the long break is due.
+//
+// A running phase has a deadline on the wall clock and the time left is
+// computed from it, so slow ticks, long pauses and suspends don't make the
+// timer drift. Methods taking the current time drop its monotonic reading
+// for that reason.
+package pomodoro
+
+import "time"
+
+// State is a state of the machine.
+type State int
+
+const (
+	// Idle is a phase that hasn't been started yet.
+	Idle State = iota
+	Working
+	ShortBreak
+	LongBreak
+	// Paused is a started phase that is on hold.
+	Paused
+	// Done is a phase that ran to its end.
+	Done
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Working:
+		return "working"
+	case ShortBreak:
+		return "short break"
+	case LongBreak:
+		return "long break"
+	case Paused:
+		return "paused"
+	case Done:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// Config holds the lengths of the phases.
+type Config struct {
+	Work       time.Duration
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	// LongBreakInterval is the number of pomodoros earning a long break.
+	LongBreakInterval int
+}
+
+// Machine tracks the phase of the timer and the pomodoros completed.
+type Machine struct {
+	cfg   Config
+	state State
+	// kind is the phase being timed, even while idle, paused or done:
+	// Working, ShortBreak or LongBreak.
+	kind   State
+	length time.Duration
+	// custom is set for segments, which don't count as pomodoros.
+	custom bool
+	// deadline is when the running phase ends; remaining is the time left
+	// while it isn't running.
+	deadline  time.Time
+	remaining time.Duration
+
+	// cycle counts the pomodoros since the last long break.
+	cycle       int
+	completed   int
+	completedOn string
+}
+
+// New returns a machine about to start a work session.
+func New(cfg Config) Machine {
+	return Machine{cfg: cfg, state: Idle, kind: Working, length: cfg.Work, remaining: cfg.Work}
+}
+
+// State returns the current state.
+func (m Machine) State() State {
+	return m.state
+}
+
+// Kind returns the phase being timed: Working, ShortBreak or LongBreak.
+func (m Machine) Kind() State {
+	return m.kind
+}
+
+// OnBreak reports whether the phase being timed is a break.
+func (m Machine) OnBreak() bool {
+	return m.kind == ShortBreak || m.kind == LongBreak
+}
+
+// Running reports whether a phase is being timed right now.
+func (m Machine) Running() bool {
+	return m.state == Working || m.state == ShortBreak || m.state == LongBreak
+}
+
+// Length returns the length of the current phase.
+func (m Machine) Length() time.Duration {
+	return m.length
+}
+
+// Remaining returns the time left of the current phase.
+func (m Machine) Remaining(now time.Time) time.Duration {
+	if !m.Running() {
+		return m.remaining
+	}
+	return max(0, m.deadline.Sub(now.Round(0)))
+}
+
+// Shift moves the deadline of a running phase by d, like when the system
+// clock was changed.
+func (m *Machine) Shift(d time.Duration) {
+	if m.Running() {
+		m.deadline = m.deadline.Add(d)
+	}
+}
+
+// Cycle returns the number of pomodoros since the last long break.
+func (m Machine) Cycle() int {
+	return m.cycle
+}
+
+// LongBreakDue reports whether enough pomodoros were completed since the
+// last long break to earn the next one.
+func (m Machine) LongBreakDue() bool {
+	return m.cycle > 0 && m.cycle%m.cfg.LongBreakInterval == 0
+}
+
+// CompletedOn returns the number of pomodoros completed on now's day.
+func (m Machine) CompletedOn(now time.Time) int {
+	if m.completedOn != now.Format(time.DateOnly) {
+		return 0
+	}
+	return m.completed
+}
+
+// SetCompleted sets the number of pomodoros completed on now's day, like
+// when picking up the day's count from the history.
+func (m *Machine) SetCompleted(n int, now time.Time) {
+	m.completed = n
+	m.completedOn = now.Format(time.DateOnly)
+}
+
+// Start starts or resumes the phase. It does nothing unless the machine is
+// idle or paused.
+func (m *Machine) Start(now time.Time) {
+	if m.state == Idle || m.state == Paused {
+		m.state = m.kind
+		m.deadline = now.Round(0).Add(m.remaining)
+	}
+}
+
+// Pause puts the running phase on hold.
+func (m *Machine) Pause(now time.Time) {
+	if m.Running() {
+		m.remaining = m.Remaining(now)
+		m.state = Paused
+	}
+}
+
+// Finish ends the running phase. A finished work session counts as a
+// completed pomodoro, starting the count over on a new day.
+func (m *Machine) Finish(now time.Time) {
+	if !m.Running() && m.state != Paused {
+		return
+	}
+	m.state = Done
+	m.remaining = 0
+
+	if m.kind == Working && !m.custom {
+		if today := now.Format(time.DateOnly); m.completedOn != today {
+			m.completedOn = today
+			m.completed = 0
+		}
+		m.completed++
+		m.cycle++
+	}
+}
+
+// Reset returns to the start of the current phase.
+func (m *Machine) Reset() {
+	m.state = Idle
+	m.remaining = m.length
+}
+
+// StartWork starts a work session.
+func (m *Machine) StartWork(now time.Time) {
+	m.kind, m.length, m.custom = Working, m.cfg.Work, false
+	m.Reset()
+	m.Start(now)
+}
+
+// StartBreak starts a break, which is a long one once the configured
+// number of pomodoros has been completed.
+func (m *Machine) StartBreak(now time.Time) {
+	m.kind, m.length, m.custom = ShortBreak, m.cfg.ShortBreak, false
+	if m.LongBreakDue() {
+		m.kind, m.length = LongBreak, m.cfg.LongBreak
+		m.cycle = 0
+	}
+	m.Reset()
+	m.Start(now)
+}
+
+// Segment switches to a custom segment, like one of a training interval,
+// without starting it. Rest segments count as short breaks, anything else
+// as work that doesn't add to the completed pomodoros.
+func (m *Machine) Segment(length time.Duration, rest bool) {
+	m.kind, m.length, m.custom = Working, length, true
+	if rest {
+		m.kind = ShortBreak
+	}
+	m.Reset()
+}
+
+// StartSegment switches to a custom segment and starts it.
+func (m *Machine) StartSegment(length time.Duration, rest bool, now time.Time) {
+	m.Segment(length, rest)
+	m.Start(now)
+}
+
+// Snapshot is the state of a machine, for picking up where it was left
+// after a restart.
+type Snapshot struct {
+	State     State         `json:"state"`
+	Kind      State         `json:"kind"`
+	Length    time.Duration `json:"length"`
+	Custom    bool          `json:"custom,omitempty"`
+	Deadline  time.Time     `json:"deadline,omitempty"`
+	Remaining time.Duration `json:"remaining"`
+	Cycle     int           `json:"cycle"`
+}
+
+// Snapshot returns the machine's state, without the completed pomodoros,
+// which the history keeps.
+func (m Machine) Snapshot() Snapshot {
+	return Snapshot{
+		State:     m.state,
+		Kind:      m.kind,
+		Length:    m.length,
+		Custom:    m.custom,
+		Deadline:  m.deadline,
+		Remaining: m.remaining,
+		Cycle:     m.cycle,
+	}
+}
+
+// Restore returns the machine to a snapshot. A running phase keeps its
+// deadline, so the time away counts.
+func (m *Machine) Restore(s Snapshot) {
+	m.state, m.kind, m.length, m.custom = s.State, s.Kind, s.Length, s.Custom
+	m.deadline, m.remaining = s.Deadline.Round(0), s.Remaining
+	m.cycle = s.Cycle
+}
@@ -0,0 +1,422 @@
+package pomodoro
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	testConfig = Config{
+		Work:              25 * time.Minute,
+		ShortBreak:        5 * time.Minute,
+		LongBreak:         15 * time.Minute,
+		LongBreakInterval: 4,
+	}
+	t0 = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+)
+
+// at returns the time d after t0.
+func at(d time.Duration) time.Time {
+	return t0.Add(d)
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(*Machine)
+		wantState     State
+		wantRemaining time.Duration
+	}{
+		{
+			name:          "idle",
+			setup:         func(*Machine) {},
+			wantState:     Working,
+			wantRemaining: 25 * time.Minute,
+		},
+		{
+			name: "paused resumes with the time left",
+			setup: func(m *Machine) {
+				m.Start(t0)
+				m.Pause(at(10 * time.Minute))
+			},
+			wantState:     Working,
+			wantRemaining: 15 * time.Minute,
+		},
+		{
+			name: "running keeps its deadline",
+			setup: func(m *Machine) {
+				m.Start(at(-5 * time.Minute))
+			},
+			wantState:     Working,
+			wantRemaining: 20 * time.Minute,
+		},
+		{
+			name: "done stays done",
+			setup: func(m *Machine) {
+				m.Start(at(-25 * time.Minute))
+				m.Finish(t0)
+			},
+			wantState:     Done,
+			wantRemaining: 0,
+		},
+		{
+			name: "break",
+			setup: func(m *Machine) {
+				m.StartBreak(at(-time.Minute))
+				m.Pause(at(-time.Minute))
+			},
+			wantState:     ShortBreak,
+			wantRemaining: 5 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			tt.setup(&m)
+			m.Start(t0)
+			if got := m.State(); got != tt.wantState {
+				t.Errorf("State() = %v, want %v", got, tt.wantState)
+			}
+			if got := m.Remaining(t0); got != tt.wantRemaining {
+				t.Errorf("Remaining() = %v, want %v", got, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestPause(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(*Machine)
+		wantState     State
+		wantRemaining time.Duration
+	}{
+		{
+			name:          "idle stays idle",
+			setup:         func(*Machine) {},
+			wantState:     Idle,
+			wantRemaining: 25 * time.Minute,
+		},
+		{
+			name: "running keeps the time left",
+			setup: func(m *Machine) {
+				m.Start(at(-10 * time.Minute))
+			},
+			wantState:     Paused,
+			wantRemaining: 15 * time.Minute,
+		},
+		{
+			name: "past the deadline",
+			setup: func(m *Machine) {
+				m.Start(at(-30 * time.Minute))
+			},
+			wantState:     Paused,
+			wantRemaining: 0,
+		},
+		{
+			name: "paused stays paused",
+			setup: func(m *Machine) {
+				m.Start(at(-10 * time.Minute))
+				m.Pause(at(-5 * time.Minute))
+			},
+			wantState:     Paused,
+			wantRemaining: 20 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			tt.setup(&m)
+			m.Pause(t0)
+			if got := m.State(); got != tt.wantState {
+				t.Errorf("State() = %v, want %v", got, tt.wantState)
+			}
+			// Paused time doesn't count.
+			if got := m.Remaining(at(time.Hour)); got != tt.wantRemaining {
+				t.Errorf("Remaining() = %v, want %v", got, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(*Machine)
+		now           time.Time
+		wantState     State
+		wantCompleted int
+		wantCycle     int
+	}{
+		{
+			name:          "idle does nothing",
+			setup:         func(*Machine) {},
+			now:           t0,
+			wantState:     Idle,
+			wantCompleted: 0,
+			wantCycle:     0,
+		},
+		{
+			name:          "work counts a pomodoro",
+			setup:         func(m *Machine) { m.Start(t0) },
+			now:           at(25 * time.Minute),
+			wantState:     Done,
+			wantCompleted: 1,
+			wantCycle:     1,
+		},
+		{
+			name: "paused work counts a pomodoro",
+			setup: func(m *Machine) {
+				m.Start(t0)
+				m.Pause(at(time.Minute))
+			},
+			now:           at(2 * time.Minute),
+			wantState:     Done,
+			wantCompleted: 1,
+			wantCycle:     1,
+		},
+		{
+			name:          "break doesn't count",
+			setup:         func(m *Machine) { m.StartBreak(t0) },
+			now:           at(5 * time.Minute),
+			wantState:     Done,
+			wantCompleted: 0,
+			wantCycle:     0,
+		},
+		{
+			name:          "segment doesn't count",
+			setup:         func(m *Machine) { m.StartSegment(time.Minute, false, t0) },
+			now:           at(time.Minute),
+			wantState:     Done,
+			wantCompleted: 0,
+			wantCycle:     0,
+		},
+		{
+			name: "new day starts the count over",
+			setup: func(m *Machine) {
+				m.SetCompleted(6, t0)
+				m.Start(t0)
+			},
+			now:           at(24 * time.Hour),
+			wantState:     Done,
+			wantCompleted: 1,
+			wantCycle:     1,
+		},
+		{
+			name: "same day adds to the count",
+			setup: func(m *Machine) {
+				m.SetCompleted(6, t0)
+				m.Start(t0)
+			},
+			now:           at(25 * time.Minute),
+			wantState:     Done,
+			wantCompleted: 7,
+			wantCycle:     1,
+		},
+		{
+			name: "done does nothing",
+			setup: func(m *Machine) {
+				m.Start(t0)
+				m.Finish(at(25 * time.Minute))
+			},
+			now:           at(26 * time.Minute),
+			wantState:     Done,
+			wantCompleted: 1,
+			wantCycle:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			tt.setup(&m)
+			m.Finish(tt.now)
+			if got := m.State(); got != tt.wantState {
+				t.Errorf("State() = %v, want %v", got, tt.wantState)
+			}
+			if got := m.CompletedOn(tt.now); got != tt.wantCompleted {
+				t.Errorf("CompletedOn() = %d, want %d", got, tt.wantCompleted)
+			}
+			if got := m.Cycle(); got != tt.wantCycle {
+				t.Errorf("Cycle() = %d, want %d", got, tt.wantCycle)
+			}
+		})
+	}
+}
+
+// work completes n pomodoros, taking breaks in between but not after the
+// last one.
+func work(m *Machine, n int) {
+	for i := range n {
+		if i > 0 {
+			m.StartBreak(t0)
+			m.Finish(t0)
+		}
+		m.StartWork(t0)
+		m.Finish(t0)
+	}
+}
+
+func TestStartBreak(t *testing.T) {
+	tests := []struct {
+		name       string
+		pomodoros  int
+		wantKind   State
+		wantLength time.Duration
+		wantCycle  int
+	}{
+		{"first", 1, ShortBreak, 5 * time.Minute, 1},
+		{"before the interval", 3, ShortBreak, 5 * time.Minute, 3},
+		{"at the interval", 4, LongBreak, 15 * time.Minute, 0},
+		{"after a long break", 5, ShortBreak, 5 * time.Minute, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			work(&m, tt.pomodoros)
+			m.StartBreak(t0)
+			if got := m.Kind(); got != tt.wantKind {
+				t.Errorf("Kind() = %v, want %v", got, tt.wantKind)
+			}
+			if got := m.State(); got != tt.wantKind {
+				t.Errorf("State() = %v, want %v", got, tt.wantKind)
+			}
+			if got := m.Length(); got != tt.wantLength {
+				t.Errorf("Length() = %v, want %v", got, tt.wantLength)
+			}
+			if got := m.Cycle(); got != tt.wantCycle {
+				t.Errorf("Cycle() = %d, want %d", got, tt.wantCycle)
+			}
+		})
+	}
+}
+
+func TestLongBreakDue(t *testing.T) {
+	tests := []struct {
+		name string
+		// skip completes pomodoros without taking breaks, so a due long
+		// break is skipped.
+		skip int
+		want bool
+	}{
+		{"none", 0, false},
+		{"before the interval", 3, false},
+		{"at the interval", 4, true},
+		{"long break skipped", 5, false},
+		{"next interval", 8, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			for range tt.skip {
+				m.StartWork(t0)
+				m.Finish(t0)
+			}
+			if got := m.Cycle(); got != tt.skip {
+				t.Errorf("Cycle() = %d, want %d", got, tt.skip)
+			}
+			if got := m.LongBreakDue(); got != tt.want {
+				t.Errorf("LongBreakDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShift(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(*Machine)
+		shift         time.Duration
+		wantRemaining time.Duration
+	}{
+		{
+			name:          "running moves the deadline",
+			setup:         func(m *Machine) { m.Start(t0) },
+			shift:         time.Hour,
+			wantRemaining: 85 * time.Minute,
+		},
+		{
+			name:          "running moves the deadline back",
+			setup:         func(m *Machine) { m.Start(t0) },
+			shift:         -10 * time.Minute,
+			wantRemaining: 15 * time.Minute,
+		},
+		{
+			name:          "idle keeps its time",
+			setup:         func(*Machine) {},
+			shift:         time.Hour,
+			wantRemaining: 25 * time.Minute,
+		},
+		{
+			name: "paused keeps its time",
+			setup: func(m *Machine) {
+				m.Start(t0)
+				m.Pause(at(5 * time.Minute))
+			},
+			shift:         time.Hour,
+			wantRemaining: 20 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			tt.setup(&m)
+			m.Shift(tt.shift)
+			if got := m.Remaining(t0); got != tt.wantRemaining {
+				t.Errorf("Remaining() = %v, want %v", got, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Machine)
+	}{
+		{"new", func(*Machine) {}},
+		{"running", func(m *Machine) { m.Start(t0) }},
+		{"paused", func(m *Machine) {
+			m.Start(t0)
+			m.Pause(at(5 * time.Minute))
+		}},
+		{"long break", func(m *Machine) {
+			work(m, 4)
+			m.StartBreak(t0)
+		}},
+		{"segment", func(m *Machine) { m.StartSegment(time.Minute, true, t0) }},
+		{"cycle", func(m *Machine) { work(m, 3) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(testConfig)
+			tt.setup(&m)
+			snap := m.Snapshot()
+
+			restored := New(testConfig)
+			restored.Restore(snap)
+			if got := restored.Snapshot(); got != snap {
+				t.Errorf("Restore(%+v).Snapshot() = %+v", snap, got)
+			}
+			for _, now := range []time.Time{t0, at(3 * time.Minute)} {
+				if got, want := restored.Remaining(now), m.Remaining(now); got != want {
+					t.Errorf("Remaining(%v) = %v, want %v", now, got, want)
+				}
+			}
+			if got, want := restored.LongBreakDue(), m.LongBreakDue(); got != want {
+				t.Errorf("LongBreakDue() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRestoreStripsMonotonic(t *testing.T) {
+	m := New(testConfig)
+	m.Start(time.Now())
+	snap := m.Snapshot()
+	snap.Deadline = time.Now().Add(time.Minute)
+
+	m.Restore(snap)
+	if got := m.Snapshot().Deadline; got != got.Round(0) {
+		t.Errorf("restored deadline %v has a monotonic reading", got)
+	}
+}
@@ -0,0 +1,87 @@
+package pomodoro
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// op is a step of a random run, applied at now.
+type op struct {
+	name  string
+	apply func(m *Machine, now time.Time, r *rand.Rand)
+}
+
+var ops = []op{
+	{"Start", func(m *Machine, now time.Time, _ *rand.Rand) { m.Start(now) }},
+	{"Pause", func(m *Machine, now time.Time, _ *rand.Rand) { m.Pause(now) }},
+	{"Finish", func(m *Machine, now time.Time, _ *rand.Rand) { m.Finish(now) }},
+	{"Reset", func(m *Machine, _ time.Time, _ *rand.Rand) { m.Reset() }},
+	{"StartWork", func(m *Machine, now time.Time, _ *rand.Rand) { m.StartWork(now) }},
+	{"StartBreak", func(m *Machine, now time.Time, _ *rand.Rand) { m.StartBreak(now) }},
+	{"Shift", func(m *Machine, _ time.Time, r *rand.Rand) {
+		m.Shift(time.Duration(r.Int64N(int64(20*time.Minute))) - 10*time.Minute)
+	}},
+}
+
+// TestMachineProperties runs random sequences of operations, checking after
+// every step that the completed count never drops, the time left never goes
+// negative and breaks follow the long break interval.
+func TestMachineProperties(t *testing.T) {
+	const (
+		runs  = 500
+		steps = 200
+	)
+	for seed := range uint64(runs) {
+		r := rand.New(rand.NewPCG(seed, seed))
+		cfg := testConfig
+		cfg.LongBreakInterval = 1 + r.IntN(5)
+
+		m := New(cfg)
+		now := t0
+		completed, cycle := 0, 0
+		var trace []string
+		for range steps {
+			// Stay within t0's day, so the completed count doesn't start over.
+			now = now.Add(time.Duration(r.Int64N(int64(3 * time.Minute))))
+			o := ops[r.IntN(len(ops))]
+			trace = append(trace, o.name)
+
+			before := m
+			o.apply(&m, now, r)
+			fail := func(format string, args ...any) {
+				t.Helper()
+				t.Fatalf("seed %d, interval %d, after %v: "+format,
+					append([]any{seed, cfg.LongBreakInterval, trace}, args...)...)
+			}
+
+			if got := m.CompletedOn(now); got < completed {
+				fail("CompletedOn() = %d, was %d", got, completed)
+			}
+			completed = m.CompletedOn(now)
+
+			if got := m.Remaining(now); got < 0 {
+				fail("Remaining() = %v", got)
+			}
+
+			switch o.name {
+			case "Finish":
+				if before.Kind() == Working && (before.Running() || before.State() == Paused) {
+					cycle++
+				}
+			case "StartBreak":
+				want := ShortBreak
+				if cycle > 0 && cycle%cfg.LongBreakInterval == 0 {
+					want = LongBreak
+					cycle = 0
+				}
+				if got := m.Kind(); got != want {
+					fail("Kind() = %v after %d pomodoros, want %v", got, before.Cycle(), want)
+				}
+			}
+			if got := m.Cycle(); got != cycle {
+				fail("Cycle() = %d, want %d", got, cycle)
+			}
+		}
+	}
+}
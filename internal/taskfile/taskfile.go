@@ -0,0 +1,199 @@
+// Package taskfile reads and writes task lists kept in plain text files,
+// either in the todo.txt format or as a Markdown checklist.
+package taskfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Task is a task read from the file.
+type Task struct {
+	Name string
+	Done bool
+	// Priority is the todo.txt priority from 'A' down to 'Z', or zero.
+	Priority byte
+}
+
+// File is a task file. Files ending in .md or .markdown are Markdown
+// checklists, anything else is todo.txt.
+type File struct {
+	path     string
+	markdown bool
+}
+
+// Open returns the task file at path. The file doesn't have to exist yet.
+func Open(path string) *File {
+	ext := strings.ToLower(filepath.Ext(path))
+	return &File{path: path, markdown: ext == ".md" || ext == ".markdown"}
+}
+
+var (
+	checklistItem = regexp.MustCompile(`^\s*[-*+] \[([ xX])\] (.+)$`)
+	todoDone      = regexp.MustCompile(`^x (\d{4}-\d{2}-\d{2} ){0,2}`)
+	todoPriority  = regexp.MustCompile(`^\(([A-Z])\) `)
+	todoDate      = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+)
+
+// Tasks returns the tasks in the file, the ones with a higher priority
+// first and otherwise in the order they are listed.
+func (f *File) Tasks() ([]Task, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if t, ok := f.parse(line); ok {
+			tasks = append(tasks, t)
+		}
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return rank(tasks[i]) < rank(tasks[j])
+	})
+	return tasks, nil
+}
+
+// rank orders tasks by priority, with tasks without one last.
+func rank(t Task) int {
+	if t.Priority == 0 {
+		return 'Z' + 1
+	}
+	return int(t.Priority)
+}
+
+func (f *File) parse(line string) (Task, bool) {
+	if f.markdown {
+		m := checklistItem.FindStringSubmatch(line)
+		if m == nil {
+			return Task{}, false
+		}
+		return Task{Name: strings.TrimSpace(m[2]), Done: m[1] != " "}, true
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Task{}, false
+	}
+
+	var t Task
+	if done := todoDone.FindString(line); done != "" {
+		t.Done = true
+		line = line[len(done):]
+	}
+	if p := todoPriority.FindStringSubmatch(line); p != nil {
+		t.Priority = p[1][0]
+		line = line[len(p[0]):]
+	}
+	line = todoDate.ReplaceAllString(line, "")
+	t.Name = strings.TrimSpace(line)
+	return t, t.Name != ""
+}
+
+// Add appends a new open task to the file.
+func (f *File) Add(name string) error {
+	line := "- [ ] " + name
+	if !f.markdown {
+		line = time.Now().Format(time.DateOnly) + " " + name
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		line = "\n" + line
+	}
+
+	out, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := out.WriteString(line + "\n"); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Complete marks the first open task with the given name as done. In
+// todo.txt that prefixes it with x and today's date, moving its priority
+// to a pri: tag as the format suggests.
+func (f *File) Complete(name string) error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		t, ok := f.parse(strings.TrimRight(line, "\r"))
+		if !ok || t.Done || t.Name != name {
+			continue
+		}
+
+		if f.markdown {
+			lines[i] = strings.Replace(line, "[ ]", "[x]", 1)
+		} else {
+			line = strings.TrimSpace(line)
+			if p := todoPriority.FindStringSubmatch(line); p != nil {
+				line = line[len(p[0]):] + " pri:" + p[1]
+			}
+			lines[i] = "x " + time.Now().Format(time.DateOnly) + " " + line
+		}
+		return f.replace([]byte(strings.Join(lines, "\n")))
+	}
+
+	return fmt.Errorf("%s: no open task %q", f.path, name)
+}
+
+// replace writes data next to the file and renames it over the file, so a
+// crash or a full disk leaves the old content rather than a truncated
+// file. It keeps the file's permissions and follows symlinks, such as to
+// a synced folder.
+func (f *File) replace(data []byte) error {
+	path, err := filepath.EvalSymlinks(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,87 @@
+// Package termcap detects what the terminal can do, so the UI degrades
+// predictably on dumb terminals, legacy Windows consoles and CI logs.
+package termcap
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+)
+
+// Capabilities describes the features of the terminal on stdout.
+type Capabilities struct {
+	// TTY is false when output goes to a file, a pipe or a CI log.
+	TTY bool
+	// Color and TrueColor report 16-color and 24-bit color support.
+	Color     bool
+	TrueColor bool
+	// Unicode reports whether box drawing and block characters render.
+	Unicode bool
+	// AltScreen reports support for the alternate screen buffer.
+	AltScreen bool
+	// OSC reports support for operating system commands such as setting
+	// the window title.
+	OSC bool
+}
+
+// Detect inspects stdout and the environment.
+func Detect() Capabilities {
+	fd := os.Stdout.Fd()
+	tty := isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+	term := os.Getenv("TERM")
+
+	if !tty || term == "dumb" || os.Getenv("CI") != "" {
+		return Capabilities{Unicode: utf8Locale()}
+	}
+
+	profile := termenv.NewOutput(os.Stdout).EnvColorProfile()
+	caps := Capabilities{
+		TTY:       true,
+		Color:     profile != termenv.Ascii,
+		TrueColor: profile == termenv.TrueColor,
+		Unicode:   utf8Locale(),
+		AltScreen: true,
+		OSC:       true,
+	}
+
+	if runtime.GOOS == "windows" && legacyConsole() {
+		caps.Unicode = false
+		caps.OSC = false
+	}
+	if strings.HasPrefix(term, "screen") && os.Getenv("TMUX") == "" {
+		// GNU screen swallows most OSC sequences.
+		caps.OSC = false
+	}
+	if term == "linux" {
+		// The Linux virtual console has no window title.
+		caps.OSC = false
+	}
+
+	return caps
+}
+
+// utf8Locale reports whether the locale uses UTF-8. Windows terminals
+// don't set a locale, so only modern ones are trusted there.
+func utf8Locale() bool {
+	if runtime.GOOS == "windows" {
+		return !legacyConsole()
+	}
+
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := strings.ToLower(os.Getenv(env)); v != "" {
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+// legacyConsole reports whether we run in the classic Windows console
+// host rather than Windows Terminal or another modern emulator.
+func legacyConsole() bool {
+	return os.Getenv("WT_SESSION") == "" &&
+		os.Getenv("TERM_PROGRAM") == "" &&
+		os.Getenv("ConEmuANSI") != "ON"
+}
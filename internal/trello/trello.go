@@ -0,0 +1,197 @@
+// Package trello uses the cards of a Trello list as tasks over Trello's
+// REST API.
+package trello
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"charm/test/internal/httpclient"
+)
+
+const api = "https://api.trello.com/1"
+
+// Cards are the cards of a Trello list, used as tasks. Cards are found by
+// name; done cards are moved to another list on the same board.
+type Cards struct {
+	id       string
+	doneName string
+	key      string
+	token    string
+	client   *http.Client
+
+	mu     sync.Mutex
+	cards  map[string]string // name to card ID
+	doneID string
+}
+
+// New returns the cards of the list with the given ID, authenticating
+// with an API key and token. Done cards are moved to the list named done.
+func New(key, token, list, done string) *Cards {
+	return &Cards{
+		id:       list,
+		doneName: done,
+		key:      key,
+		token:    token,
+		client:   httpclient.New(time.Second * 10),
+		cards:    map[string]string{},
+	}
+}
+
+type card struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// do calls the API and decodes the answer into v, unless v is nil.
+func (l *Cards) do(ctx context.Context, method, path string, params url.Values, v any) error {
+	u := api + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	// The header keeps the token out of URLs, which end up in errors.
+	req.Header.Set("Authorization",
+		fmt.Sprintf(`OAuth oauth_consumer_key="%s", oauth_token="%s"`, l.key, l.token))
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("trello: %s %s: %s", method, path, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// List returns the names of the cards in the list, in the list's order.
+func (l *Cards) List(ctx context.Context) ([]string, error) {
+	var cards []card
+	params := url.Values{"fields": {"name"}}
+	if err := l.do(ctx, http.MethodGet, "/lists/"+l.id+"/cards", params, &cards); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names := make([]string, len(cards))
+	l.cards = make(map[string]string, len(cards))
+	for i, c := range cards {
+		names[i] = c.Name
+		l.cards[c.Name] = c.ID
+	}
+	return names, nil
+}
+
+// Add adds a card to the bottom of the list.
+func (l *Cards) Add(ctx context.Context, name string) error {
+	var c card
+	params := url.Values{"idList": {l.id}, "name": {name}, "pos": {"bottom"}}
+	if err := l.do(ctx, http.MethodPost, "/cards", params, &c); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cards[c.Name] = c.ID
+	return nil
+}
+
+// Complete moves the named card to the done list.
+func (l *Cards) Complete(ctx context.Context, name string) error {
+	id, err := l.card(ctx, name)
+	if err != nil {
+		return err
+	}
+	done, err := l.doneList(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{"idList": {done}, "pos": {"top"}}
+	if err := l.do(ctx, http.MethodPut, "/cards/"+id, params, nil); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cards, name)
+	return nil
+}
+
+// Annotate adds a comment to the named card.
+func (l *Cards) Annotate(ctx context.Context, name, text string) error {
+	id, err := l.card(ctx, name)
+	if err != nil {
+		return err
+	}
+	return l.do(ctx, http.MethodPost, "/cards/"+id+"/actions/comments", url.Values{"text": {text}}, nil)
+}
+
+// card returns the ID of the named card, reloading the list if it was
+// added in the meantime.
+func (l *Cards) card(ctx context.Context, name string) (string, error) {
+	l.mu.Lock()
+	id, ok := l.cards[name]
+	l.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	if _, err := l.List(ctx); err != nil {
+		return "", err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id, ok := l.cards[name]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("trello: no card %q in the list", name)
+}
+
+// doneList returns the ID of the done list, looking it up by name on the
+// list's board the first time.
+func (l *Cards) doneList(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	done := l.doneID
+	l.mu.Unlock()
+	if done != "" {
+		return done, nil
+	}
+
+	var list struct {
+		IDBoard string `json:"idBoard"`
+	}
+	if err := l.do(ctx, http.MethodGet, "/lists/"+l.id, url.Values{"fields": {"idBoard"}}, &list); err != nil {
+		return "", err
+	}
+	var lists []card
+	params := url.Values{"fields": {"name"}}
+	if err := l.do(ctx, http.MethodGet, "/boards/"+list.IDBoard+"/lists", params, &lists); err != nil {
+		return "", err
+	}
+	for _, c := range lists {
+		if strings.EqualFold(c.Name, l.doneName) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.doneID = c.ID
+			return c.ID, nil
+		}
+	}
+	return "", fmt.Errorf("trello: no list named %q on the board", l.doneName)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/events"
+)
+
+// intervalMode reports whether the timer runs a custom repeating segment
+// list instead of the pomodoro cycle.
+func (m model) intervalMode() bool {
+	return len(m.intervals) > 0
+}
+
+// startSegment starts segment i of the interval list, wrapping around at
+// the end so the routine repeats.
+func (m model) startSegment(i int) (tea.Model, tea.Cmd) {
+	m = m.endSession(false)
+	progressCmd := m.progress.SetPercent(0.0)
+
+	m.segment = i % len(m.intervals)
+	seg := m.intervals[m.segment]
+
+	m.pomo.StartSegment(seg.length, seg.rest(), time.Now())
+	m.timer = m.newTimer(m.pomo.Length())
+	m = m.beginSession()
+	return m, tea.Batch(progressCmd, m.timer.Start())
+}
+
+// segmentFinished records the finished segment, announces the next one
+// and starts it right away.
+func (m model) segmentFinished(timerCmd tea.Cmd) (tea.Model, tea.Cmd) {
+	finished := m.intervals[m.segment]
+	upcoming := m.intervals[(m.segment+1)%len(m.intervals)]
+
+	m = m.endSession(true).emit(events.PhaseEnded{
+		At:    time.Now(),
+		Title: finished.label + " finished",
+		Body:  fmt.Sprintf("Next: %s for %s", upcoming.label, formatDuration(upcoming.length, formatCompact)),
+	})
+	next, nextCmd := m.startSegment(m.segment + 1)
+
+	return next, tea.Batch(timerCmd, nextCmd)
+}
+
+// segmentView shows where in the interval list the timer is.
+func (m model) segmentView() string {
+	return fmt.Sprintf("\n%d/%d  %s", m.segment+1, len(m.intervals), m.intervals[m.segment].label)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm/test/internal/history"
+)
+
+// Events in the session journal.
+const (
+	journalStart = "start"
+	journalEnd   = "end"
+)
+
+// journalEntry is a line of the session journal. Sessions are journaled
+// when they start, so one still shows up in the history after a crash or
+// power loss that kept it from being recorded when it ended. Every process
+// keeps its own journal, named after its PID, so the app and the daemon
+// don't take each other's running sessions for interrupted ones.
+type journalEntry struct {
+	Event     string    `json:"event"`
+	Start     time.Time `json:"start"`
+	Phase     string    `json:"phase,omitempty"`
+	Intention string    `json:"intention,omitempty"`
+	Task      string    `json:"task,omitempty"`
+}
+
+// journalPath returns the journal of the process with the given PID.
+func journalPath(pid int) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("journal-%d.jsonl", pid)), nil
+}
+
+// appendJournal appends an entry to the journal and syncs it to disk.
+func appendJournal(e journalEntry) error {
+	path, err := journalPath(os.Getpid())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// reconcileJournal records the sessions journaled as started but never
+// ended by processes that are gone as interrupted, then removes their
+// journals. Journals of running processes are left alone.
+func reconcileJournal(ctx context.Context, store *history.Store) error {
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "journal*.jsonl*"))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range paths {
+		if pid, ok := journalOwner(filepath.Base(path)); ok && pid != os.Getpid() && processAlive(pid) {
+			continue
+		}
+		errs = append(errs, reconcileFile(ctx, store, path))
+	}
+	return errors.Join(errs...)
+}
+
+// journalOwner returns the PID of the process a journal belongs to: the
+// one that wrote journal-PID.jsonl, or the one reconciling it as
+// journal-PID.jsonl.OWNER. journal.jsonl of earlier versions has none.
+func journalOwner(name string) (int, bool) {
+	if i := strings.LastIndex(name, ".jsonl."); i >= 0 {
+		pid, err := strconv.Atoi(name[i+len(".jsonl."):])
+		return pid, err == nil
+	}
+	var pid int
+	_, err := fmt.Sscanf(name, "journal-%d.jsonl", &pid)
+	return pid, err == nil
+}
+
+// reconcileFile records the open sessions of a dead process's journal and
+// removes it. The journal is claimed by renaming it first, so another
+// process reconciling at the same time doesn't record them twice.
+func reconcileFile(ctx context.Context, store *history.Store, path string) error {
+	claimed := fmt.Sprintf("%s.%d", path[:strings.LastIndex(path, ".jsonl")+len(".jsonl")], os.Getpid())
+	if err := os.Rename(path, claimed); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	f, err := os.Open(claimed)
+	if err != nil {
+		return err
+	}
+	open, err := openSessions(f)
+	// Windows doesn't remove open files.
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range open {
+		err := store.Add(ctx, history.Session{
+			Start:       e.Start,
+			End:         e.Start,
+			Phase:       e.Phase,
+			Intention:   e.Intention,
+			Task:        e.Task,
+			Interrupted: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return os.Remove(claimed)
+}
+
+// openSessions returns the sessions a journal has a start but no end for.
+func openSessions(r io.Reader) ([]journalEntry, error) {
+	var open []journalEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var e journalEntry
+		// A crash mid-write leaves a torn last line; skip it.
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		switch e.Event {
+		case journalStart:
+			open = append(open, e)
+		case journalEnd:
+			for i, o := range open {
+				if o.Start.Equal(e.Start) {
+					open = append(open[:i], open[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	return open, scanner.Err()
+}
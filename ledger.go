@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"charm/test/internal/history"
+)
+
+// ledgerLine formats a session for the ledger. Org and Markdown files get
+// list items, anything else a tab-separated line.
+func ledgerLine(path string, s history.Session) string {
+	status := "completed"
+	if !s.Completed {
+		status = "aborted"
+	}
+	length := formatDuration(s.Duration, formatCompact)
+
+	var what []string
+	for _, v := range []string{s.Task, s.Intention} {
+		if v != "" {
+			what = append(what, v)
+		}
+	}
+	note := strings.Join(what, ": ")
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".org":
+		line := fmt.Sprintf("- [%s]--[%s] %s %s %s",
+			s.Start.Format(orgTimestamp), s.End.Format(orgTimestamp), s.Phase, length, status)
+		if note != "" {
+			line += " :: " + note
+		}
+		return line
+	case ".md", ".markdown":
+		line := fmt.Sprintf("- %s %s-%s **%s** %s, %s",
+			s.Start.Format(time.DateOnly), s.Start.Format("15:04"), s.End.Format("15:04"), s.Phase, length, status)
+		if note != "" {
+			line += ": " + note
+		}
+		return line
+	default:
+		return strings.Join([]string{
+			s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.Phase, length, status, note,
+		}, "\t")
+	}
+}
+
+// appendLedger appends a line for the session to the ledger at path.
+func appendLedger(path string, s history.Session) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(ledgerLine(path, s) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -1,67 +1,218 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/timer"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-)
-
-var timeout = time.Minute * 25
+	"github.com/muesli/termenv"
 
-var percent float64 = 0.0
+	"charm/test/internal/events"
+	"charm/test/internal/history"
+	"charm/test/internal/indicator"
+	"charm/test/internal/notify"
+	"charm/test/internal/otlp"
+	"charm/test/internal/pomodoro"
+	"charm/test/internal/termcap"
+)
 
 const (
 	padding  = 2
 	maxWidth = 80
 )
 
+// asciiBorder replaces box drawing characters on terminals without
+// Unicode support.
+var asciiBorder = lipgloss.Border{
+	Top: "-", Bottom: "-", Left: "|", Right: "|",
+	TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+}
+
+// askPauseReason controls whether pausing a work session prompts for
+// the reason of the interruption.
+var askPauseReason = true
+
+// pauseReasons are the interruption sources offered when pausing a work
+// session, each chosen with a single key.
+var pauseReasons = defaultConfig().reasonBindings()
+
+var skipReason = key.NewBinding(
+	key.WithKeys("esc", "enter"),
+	key.WithHelp("esc", "skip"),
+)
+
 type model struct {
-	timer    timer.Model
-	keymap   keymap
-	help     help.Model
-	quitting bool
-	progress progress.Model
+	cfg             config
+	caps            termcap.Capabilities
+	kiosk           bool
+	lastPhase       string
+	pomo            pomodoro.Machine
+	intervals       []segment
+	deadline        deadline
+	segment         int
+	history         *history.Store
+	sessionStart    time.Time
+	sessionLength   time.Duration
+	clockCheck      time.Time
+	intentionInput  textinput.Model
+	askingIntention bool
+	renaming        bool
+	intention       string
+	tasks           list.Model
+	taskSource      taskProvider
+	taskInput       textinput.Model
+	choosingTask    bool
+	addingTask      bool
+	task            string
+	doneTasks       []string
+	hideHelp        bool
+	bigClock        bool
+	askingResume    bool
+	resume          *timerState
+	undo            []undoEntry
+	toast           string
+	toastID         int
+	width           int
+	height          int
+	timer           timer.Model
+	keymap          keymap
+	help            help.Model
+	quitting        bool
+	progress        progress.Model
+	goalProgress    progress.Model
+	askingReason    bool
+	interrupts      map[string]int
+	jobs            *runner
+	jobErr          error
+	queue           *events.Queue
+	pending         []events.Event
+	recent          *eventLog
+	pairMode        bool
+	pausedBy        string
+	hostReady       bool
+	guestReady      bool
+	capture         textinput.Model
+	capturing       bool
+	parked          []string
+	parkCursor      int
+	askingReview    bool
+	reviewID        int
+	reviewStart     time.Time
+	reviews         map[string]int
+	// title is the last countdown put in the terminal's title.
+	title string
 }
 
 type tickMsg time.Time
 
 type keymap struct {
-	start      key.Binding
-	pauseTimer key.Binding
-	workTimer  key.Binding
-	stop       key.Binding
-	reset      key.Binding
-	quit       key.Binding
+	start       key.Binding
+	pauseTimer  key.Binding
+	workTimer   key.Binding
+	stop        key.Binding
+	reset       key.Binding
+	quit        key.Binding
+	pair        key.Binding
+	guestToggle key.Binding
+	guestReady  key.Binding
+	park        key.Binding
+	tasks       key.Binding
+	name        key.Binding
+	toggleHelp  key.Binding
+	bigClock    key.Binding
+	debug       key.Binding
+	undo        key.Binding
+	copyStatus  key.Binding
+	copyToday   key.Binding
+	parkUp      key.Binding
+	parkDown    key.Binding
+	discard     key.Binding
+	promote     key.Binding
 }
 
 func (m model) Init() tea.Cmd {
+	var metricsCmd tea.Cmd
+	if textfilePath != "" {
+		metricsCmd = metricsTick()
+	}
+
+	var tasksCmd tea.Cmd
+	if _, ok := m.taskSource.(*cachedTasks); ok {
+		tasksCmd = m.loadTasks()
+	}
+
+	if m.kiosk {
+		return tea.Batch(tickCmd(), m.timer.Start(), metricsCmd, tasksCmd)
+	}
+
 	return tea.Batch(
 		tickCmd(),
 		m.timer.Stop(),
+		metricsCmd,
+		tasksCmd,
 	)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+
+	m = next.(model)
+	if phase := m.phase(); phase != m.lastPhase {
+		var phaseCmd tea.Cmd
+		m, phaseCmd = m.phaseChanged(m.lastPhase, phase)
+		cmd = tea.Batch(cmd, phaseCmd)
+		m.lastPhase = phase
+	}
+	m, eventsCmd := m.publish()
+	m, titleCmd := m.updateTitle()
+	return m, tea.Batch(cmd, eventsCmd, titleCmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case timer.TickMsg:
 		var cmd tea.Cmd
-		percent = ((timeout.Seconds() - m.timer.Timeout.Seconds()) / timeout.Seconds())
-
-		progressCmd := m.progress.SetPercent(float64(percent))
+		m = m.checkClock()
+		if msg.ID == m.timer.ID() && m.pomo.Running() {
+			// The timer takes an interval off per tick. Hand it the
+			// time left until the deadline instead of trusting ticks.
+			// Whole seconds, so the countdown reads the same at any
+			// tick interval.
+			left := m.pomo.Remaining(time.Now())
+			m.timer.Timeout = (left + time.Second - 1).Truncate(time.Second) + m.timer.Interval
+		}
 		m.timer, cmd = m.timer.Update(msg)
+		progressCmd := m.progress.SetPercent(m.elapsed())
 
 		return m, tea.Batch(progressCmd, cmd)
 
 	case timer.StartStopMsg:
 		var cmd tea.Cmd
 		m.timer, cmd = m.timer.Update(msg)
+		if msg.ID == m.timer.ID() {
+			if m.timer.Running() {
+				m.pomo.Start(time.Now())
+			} else {
+				m.pomo.Pause(time.Now())
+			}
+		}
 		m.keymap.stop.SetEnabled(m.timer.Running())
 		m.keymap.start.SetEnabled(!m.timer.Running())
 		return m, cmd
@@ -69,35 +220,171 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case timer.TimeoutMsg:
 		var cmd tea.Cmd
 		m.timer, cmd = m.timer.Update(msg)
-		m.quitting = true
 		m.keymap.stop.SetEnabled(m.timer.Running())
 		m.keymap.start.SetEnabled(!m.timer.Running())
-		return m, cmd
+		m.pomo.Finish(time.Now())
+
+		if m.intervalMode() {
+			return m.segmentFinished(cmd)
+		}
+
+		title := "Work session finished"
+		alert := "Time for a break."
+		if m.pomo.OnBreak() {
+			title = "Break finished"
+			if m.pomo.Kind() == pomodoro.LongBreak {
+				title = "Long break finished"
+			}
+			alert = "Back to work."
+		} else {
+			if m.pomo.LongBreakDue() {
+				alert = "Time for a long break."
+			}
+			if m.goalJustReached() {
+				alert = fmt.Sprintf("Daily goal of %d pomodoros reached, well done! %s", m.cfg.DailyGoal, alert)
+				m = m.emit(events.GoalReached{At: time.Now(), Goal: m.cfg.DailyGoal})
+			}
+		}
+		start := m.sessionStart
+		m = m.endSession(true).emit(events.PhaseEnded{At: time.Now(), Title: title, Body: alert})
+
+		var reviewCmd tea.Cmd
+		if askReview && !m.pomo.OnBreak() {
+			m, reviewCmd = m.promptReview(start)
+		}
+
+		var nextCmd tea.Cmd
+		if m.cfg.AutoStart {
+			var next tea.Model
+			switch {
+			case !m.pomo.OnBreak():
+				next, nextCmd = m.startBreak()
+			case m.pairMode:
+				// Pairs start work through the ready handshake.
+				next = m
+			default:
+				next, nextCmd = m.beginWork()
+			}
+			m = next.(model)
+		}
+		return m, tea.Batch(cmd, reviewCmd, nextCmd)
+
+	case tickMsg:
+		// The deadline banner only changes once a minute, which the
+		// timer doesn't redraw for while stopped.
+		if !m.deadline.at.IsZero() {
+			return m, tickCmd()
+		}
+		return m, nil
+
+	case metricsTickMsg:
+		return m, tea.Batch(m.writeMetrics(), metricsTick())
+
+	case toastExpiredMsg:
+		if msg.id == m.toastID {
+			m.toast = ""
+		}
+		return m, nil
+
+	case reviewExpiredMsg:
+		if msg.id == m.reviewID {
+			m.askingReview = false
+		}
+		return m, nil
+
+	case tasksMsg:
+		return m.updateTaskItems(msg)
+
+	case taskDueMsg:
+		return m.updateTaskDue(msg)
+
+	case apiMsg:
+		return m.updateAPI(msg)
+
+	case jobDoneMsg:
+		m.jobErr = nil
+		var suppressed suppressedError
+		if errors.As(msg.err, &suppressed) {
+			return m.showToast("Alert suppressed: " + suppressed.reason)
+		}
+		if msg.err != nil {
+			m.jobErr = fmt.Errorf("%s failed: %w", msg.name, msg.err)
+		}
+		return m, nil
 
 	case tea.KeyMsg:
+		if m.askingResume {
+			return m.updateResume(msg)
+		}
+		if m.askingReason {
+			return m.updatePauseReason(msg)
+		}
+		if m.askingReview {
+			return m.updateReviewAnswer(msg)
+		}
+		if m.capturing {
+			return m.updateCapture(msg)
+		}
+		if m.askingIntention {
+			return m.updateIntention(msg)
+		}
+		if m.choosingTask {
+			return m.updateTasks(msg)
+		}
+		if m.reviewing() {
+			if m, cmd, ok := m.updateReview(msg); ok {
+				return m, cmd
+			}
+		}
+
 		switch {
 		case key.Matches(msg, m.keymap.quit):
-			m.quitting = true
-			return m, tea.Quit
+			return m.quit()
 		case key.Matches(msg, m.keymap.reset):
-			progressCmd := m.progress.SetPercent(0.0)
-			m.timer = timer.New(timeout)
-
-			m.keymap.start.SetEnabled(true)
-
-			return m, tea.Batch(progressCmd, m.timer.Stop())
+			return m.reset()
 		case key.Matches(msg, m.keymap.start, m.keymap.stop):
-			return m, m.timer.Toggle()
+			return m.toggle("host")
+		case key.Matches(msg, m.keymap.guestToggle):
+			return m.toggle("guest")
+		case key.Matches(msg, m.keymap.pair):
+			m.pairMode = !m.pairMode
+			m.hostReady, m.guestReady = false, false
+			m.keymap.guestToggle.SetEnabled(m.pairMode)
+			m.keymap.guestReady.SetEnabled(m.pairMode)
+			return m, nil
+		case key.Matches(msg, m.keymap.guestReady):
+			m.guestReady = true
+			return m.startWork()
+		case m.intervalMode() && key.Matches(msg, m.keymap.pauseTimer):
+			return m.undoableSession("skip").startSegment(m.segment + 1)
+		case m.intervalMode() && key.Matches(msg, m.keymap.workTimer):
+			return m.undoableSession("restart").startSegment(0)
 		case key.Matches(msg, m.keymap.pauseTimer):
-			progressCmd := m.progress.SetPercent(0.0)
-			timeout = time.Minute * 5
-			m.timer = timer.New(timeout)
-			return m, tea.Batch(progressCmd, m.timer.Start())
+			return m.undoableSession("skip to break").startBreak()
 		case key.Matches(msg, m.keymap.workTimer):
-			progressCmd := m.progress.SetPercent(0.0)
-			timeout = time.Minute * 25
-			m.timer = timer.New(timeout)
-			return m, tea.Batch(progressCmd, m.timer.Start())
+			m.hostReady = true
+			return m.undoableSession("skip to work").startWork()
+		case key.Matches(msg, m.keymap.undo):
+			return m.undoLast()
+		case key.Matches(msg, m.keymap.copyStatus):
+			return m.copyStatus()
+		case key.Matches(msg, m.keymap.copyToday):
+			return m.copySummary()
+		case key.Matches(msg, m.keymap.park):
+			m.capturing = true
+			return m, m.capture.Focus()
+		case key.Matches(msg, m.keymap.tasks):
+			return m.openTasks()
+		case key.Matches(msg, m.keymap.name):
+			return m.renameSession()
+		case key.Matches(msg, m.keymap.toggleHelp):
+			m.hideHelp = !m.hideHelp
+			return m, nil
+		case key.Matches(msg, m.keymap.bigClock):
+			m.bigClock = !m.bigClock
+			return m, nil
+		case key.Matches(msg, m.keymap.debug):
+			return m.saveDebugSnapshot()
 		}
 
 	case progress.FrameMsg:
@@ -105,40 +392,344 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.progress = progressModel.(progress.Model)
 		return m, cmd
 	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 		m.progress.Width = msg.Width - padding*2 - 4
 		if m.progress.Width > maxWidth {
 			m.progress.Width = maxWidth
 		}
+		m.goalProgress.Width = m.progress.Width
 		return m, nil
 	default:
+		if m.capturing {
+			var cmd tea.Cmd
+			m.capture, cmd = m.capture.Update(msg)
+			return m, cmd
+		}
+		if m.askingIntention {
+			var cmd tea.Cmd
+			m.intentionInput, cmd = m.intentionInput.Update(msg)
+			return m, cmd
+		}
+		if m.addingTask {
+			var cmd tea.Cmd
+			m.taskInput, cmd = m.taskInput.Update(msg)
+			return m, cmd
+		}
+		if m.choosingTask {
+			var cmd tea.Cmd
+			m.tasks, cmd = m.tasks.Update(msg)
+			return m, cmd
+		}
 		return m, nil
 	}
 
 	return m, nil
 }
 
+// quit records the running session and quits. main cancels and drains
+// the remaining jobs and saves the state once the program returned.
+func (m model) quit() (tea.Model, tea.Cmd) {
+	m.quitting = true
+	// Keep the session to offer resuming it on the next start. It's
+	// paused, so the time away doesn't count; only a session cut short by
+	// a crash keeps running.
+	if !m.askingResume {
+		paused := m
+		paused.pomo.Pause(time.Now())
+		m.resume = paused.timerState()
+	}
+	// The session has to be recorded before the jobs are cancelled.
+	m, eventsCmd := m.endSession(false).publish()
+	// So are the tasks marked done while they could still be undone.
+	return m, tea.Sequence(eventsCmd, m.finishTasks(m.doneTasks...), tea.Quit)
+}
+
+// startBreak starts a break, which is a long one once the configured
+// number of pomodoros has been completed.
+func (m model) startBreak() (tea.Model, tea.Cmd) {
+	m = m.endSession(false)
+	progressCmd := m.progress.SetPercent(0.0)
+
+	m.pomo.StartBreak(time.Now())
+	m.timer = m.newTimer(m.pomo.Length())
+	m = m.beginSession()
+	return m, tea.Batch(progressCmd, m.timer.Start())
+}
+
+// reset returns to the start of the current phase.
+func (m model) reset() (tea.Model, tea.Cmd) {
+	m = m.undoableSession("reset")
+	m = m.endSession(false)
+	progressCmd := m.progress.SetPercent(0.0)
+	m.pomo.Reset()
+	m.timer = m.newTimer(m.pomo.Length())
+	m.pausedBy = ""
+
+	m.keymap.start.SetEnabled(true)
+
+	return m, tea.Batch(progressCmd, m.timer.Stop())
+}
+
+// startWork starts a work session. In pair mode both participants have to
+// be ready first, so nobody gets pulled back early from a break.
+func (m model) startWork() (tea.Model, tea.Cmd) {
+	if m.pairMode && !(m.hostReady && m.guestReady) {
+		return m, nil
+	}
+	m.hostReady, m.guestReady = false, false
+
+	m = m.endSession(false)
+	if m.cfg.AskIntention {
+		return m.promptIntention()
+	}
+	return m.beginWork()
+}
+
+// beginWork starts the work session timer.
+func (m model) beginWork() (tea.Model, tea.Cmd) {
+	progressCmd := m.progress.SetPercent(0.0)
+	m.pomo.StartWork(time.Now())
+	m.timer = m.newTimer(m.pomo.Length())
+	m = m.beginSession()
+	return m, tea.Batch(progressCmd, m.timer.Start())
+}
+
+// cycleView prompts for the next phase once the timer ran out and shows
+// progress towards the next long break otherwise.
+func (m model) cycleView() string {
+	switch {
+	case m.timer.Timedout() && m.pomo.OnBreak():
+		return fmt.Sprintf("\nPress %s to get back to work", m.keymap.workTimer.Help().Key)
+	case m.timer.Timedout() && m.pomo.LongBreakDue():
+		return fmt.Sprintf("\nPress %s to start your %s long break",
+			m.keymap.pauseTimer.Help().Key, formatDuration(m.cfg.LongBreak.Duration, formatCompact))
+	case m.timer.Timedout():
+		return fmt.Sprintf("\nPress %s to start the break", m.keymap.pauseTimer.Help().Key)
+	case m.pomo.LongBreakDue() && !m.pomo.OnBreak():
+		return fmt.Sprintf("\nLong break due, press %s for %s off",
+			m.keymap.pauseTimer.Help().Key, formatDuration(m.cfg.LongBreak.Duration, formatCompact))
+	default:
+		return ""
+	}
+}
+
+// sessionsView shows today's completed pomodoros as filled dots, the rest
+// of the current cycle as empty ones, and the current phase.
+func (m model) sessionsView() string {
+	if m.intervalMode() {
+		return m.segmentView()
+	}
+
+	filled, empty := "●", "○"
+	if !m.caps.Unicode {
+		filled, empty = "*", "o"
+	}
+
+	// A skipped long break leaves the cycle past the interval, until the
+	// next break is a long one.
+	remaining := m.cfg.LongBreakInterval - m.pomo.Cycle()%m.cfg.LongBreakInterval
+	if m.pomo.LongBreakDue() {
+		remaining = 0
+	}
+
+	return "\n" + strings.Repeat(filled, m.completedToday()) + strings.Repeat(empty, remaining) +
+		"  " + m.phaseLabel()
+}
+
+// phaseLabel names the current phase for display.
+func (m model) phaseLabel() string {
+	switch m.phase() {
+	case phaseWork:
+		return "Work"
+	case phaseBreak:
+		if m.pomo.Kind() == pomodoro.LongBreak {
+			return "Long break"
+		}
+		return "Break"
+	case phasePaused:
+		return "Paused"
+	case phaseDone:
+		return "Done"
+	default:
+		return "Ready"
+	}
+}
+
+// readyView shows who is still holding up the next work session.
+func (m model) readyView() string {
+	switch {
+	case m.hostReady && !m.guestReady:
+		return "\nWaiting for guest to be ready..."
+	case m.guestReady && !m.hostReady:
+		return "\nWaiting for host to be ready..."
+	default:
+		return ""
+	}
+}
+
+// toggle starts or pauses the timer on behalf of who.
+func (m model) toggle(who string) (tea.Model, tea.Cmd) {
+	m.pausedBy = ""
+	if m.timer.Running() {
+		m.pausedBy = who
+		m.askingReason = askPauseReason && !m.pomo.OnBreak()
+	} else if m.sessionStart.IsZero() {
+		m = m.beginSession()
+	}
+
+	return m, m.timer.Toggle()
+}
+
+// updatePauseReason records the interruption source picked after pausing
+// a work session.
+func (m model) updatePauseReason(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, skipReason) {
+		m.askingReason = false
+		return m, nil
+	}
+
+	for _, reason := range pauseReasons {
+		if key.Matches(msg, reason) {
+			m.interrupts[reason.Help().Desc]++
+			m.askingReason = false
+			return m.emit(events.Interrupted{At: time.Now(), Start: m.sessionStart, Reason: reason.Help().Desc}), nil
+		}
+	}
+
+	return m, nil
+}
+
+// interruptsView lists the recorded pause reasons, most frequent first.
+func (m model) interruptsView() string {
+	if len(m.interrupts) == 0 {
+		return ""
+	}
+
+	return "\nInterruptions: " + strings.Join(rankReasons(m.interrupts), m.help.ShortSeparator)
+}
+
+// rankReasons lists pause reasons with their counts, most frequent first.
+func rankReasons(counts map[string]int) []string {
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+
+	for i, reason := range reasons {
+		reasons[i] = fmt.Sprintf("%s %d", reason, counts[reason])
+	}
+	return reasons
+}
+
 func (m model) helpView() string {
-	return "\n" + m.help.ShortHelpView([]key.Binding{
+	if m.askingResume {
+		return m.resumeView()
+	}
+	if m.askingReason {
+		return "\nWhy the pause?\n" +
+			m.help.ShortHelpView(append(pauseReasons, skipReason))
+	}
+
+	if m.askingReview {
+		return "\nDid you finish what you planned?\n" +
+			m.help.ShortHelpView(append(reviewAnswers, skipReason))
+	}
+	if m.capturing {
+		return ""
+	}
+	if m.askingIntention {
+		return m.intentionPromptView()
+	}
+	if m.choosingTask {
+		return ""
+	}
+
+	bindings := []key.Binding{
 		m.keymap.start,
 		m.keymap.stop,
 		m.keymap.reset,
 		m.keymap.quit,
 		m.keymap.pauseTimer,
 		m.keymap.workTimer,
-	})
+		m.keymap.pair,
+		m.keymap.guestToggle,
+		m.keymap.guestReady,
+		m.keymap.park,
+		m.keymap.tasks,
+		m.keymap.name,
+		m.keymap.undo,
+		m.keymap.copyStatus,
+		m.keymap.copyToday,
+		m.keymap.bigClock,
+		m.keymap.debug,
+		m.keymap.toggleHelp,
+	}
+	if m.reviewing() {
+		bindings = append(bindings, m.keymap.parkUp, m.keymap.parkDown, m.keymap.discard, m.keymap.promote)
+	}
+
+	if m.hideHelp {
+		bindings = []key.Binding{m.keymap.toggleHelp}
+	}
+
+	return "\n" + m.help.ShortHelpView(bindings)
+}
+
+// kioskView fills the screen with the countdown in large digits, readable
+// from across the room on a dedicated display.
+func (m model) kioskView() string {
+	phase := "WORK"
+	if m.pomo.OnBreak() {
+		phase = "BREAK"
+	}
+
+	clock := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.cfg.Colors.Text)).
+		Render(bigText(formatDuration(m.timer.Timeout, formatClock), m.caps.Unicode))
+
+	body := lipgloss.JoinVertical(lipgloss.Center,
+		phase, "", clock, "", m.progressView(), m.deadlineView(), m.helpView())
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, body)
 }
 
 func (m model) View() string {
-	s := m.timer.View()
+	if m.kiosk {
+		return m.kioskView()
+	}
+
+	s := formatDuration(m.timer.Timeout, durationFormat)
+	if m.bigClock {
+		// Under the big digits, the phase takes the small countdown's
+		// place.
+		s = bigText(formatDuration(m.timer.Timeout, formatClock), m.caps.Unicode) + "\n\n" + m.phaseLabel()
+	}
 
 	if m.timer.Timedout() {
-		s = "All done!"
+		s = "Work session done!"
+		if m.pomo.OnBreak() {
+			s = "Break is over!"
+		}
+	} else if m.pairMode && m.pausedBy != "" && !m.timer.Running() {
+		s += " (paused by " + m.pausedBy + ")"
+	}
+	s += m.taskView() + m.intentionView()
+
+	border := lipgloss.NormalBorder()
+	if !m.caps.Unicode {
+		border = asciiBorder
 	}
 
 	var style = lipgloss.NewStyle().
 		Bold(true).
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#7D56F4")).
+		BorderStyle(border).
+		BorderForeground(lipgloss.Color(m.cfg.Colors.Border)).
 		PaddingLeft(1).
 		PaddingRight(1).
 		PaddingTop(1).
@@ -149,15 +740,23 @@ func (m model) View() string {
 
 	var textStyle = lipgloss.NewStyle().
 		Bold(true).
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#7D56F4")).
+		BorderStyle(border).
+		BorderForeground(lipgloss.Color(m.cfg.Colors.Border)).
 		PaddingLeft(2).
 		Width(82).
 		PaddingBottom(1).
 		BorderTop(true).
-		Foreground(lipgloss.Color("63"))
+		Foreground(lipgloss.Color(m.cfg.Colors.Text))
 
-	prog := m.progress.View() + m.helpView()
+	if banner := m.deadlineView(); banner != "" {
+		s += "\n" + banner
+	}
+
+	prog := m.progressView() + m.sessionsView() + m.goalView() + m.cycleView() + m.helpView() + m.readyView() +
+		m.parkingView() + m.tasksView() + m.interruptsView() + m.reviewView() + m.toastView()
+	if m.jobErr != nil {
+		prog += "\n" + m.jobErr.Error()
+	}
 
 	return (textStyle.Render(s) + style.Render(prog))
 }
@@ -168,12 +767,241 @@ func tickCmd() tea.Cmd {
 	})
 }
 
+// runCommand runs a subcommand instead of the interactive timer.
+func runCommand(args []string) error {
+	switch args[0] {
+	case "plan":
+		return runPlan(args[1:])
+	case "doctor":
+		return runDoctor(args[1:])
+	case "stats":
+		return runStats(args[1:])
+	case "export":
+		return runExport(args[1:])
+	case "standup":
+		return runStandup(args[1:])
+	case "secret":
+		return runSecret(args[1:])
+	case "auth":
+		return runAuth(args[1:])
+	case "daemon":
+		return runDaemon(args[1:])
+	case daemonStatus:
+		return runStatus(args[1:])
+	case daemonStart, daemonPause, daemonSkip:
+		return runClient(args[0], args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
 func main() {
+	work := flag.Duration("work", 0, "length of a work session, e.g. 50m (overrides the config file)")
+	brk := flag.Duration("break", 0, "length of a break, e.g. 10m (overrides the config file)")
+	intervals := flag.String("intervals", "", `repeating segments instead of the pomodoro cycle, e.g. "3x(2m:write 30s:rest)"`)
+	deadlineAt := flag.String("deadline", "", `hard stop shown next to the timer, e.g. "16:00 demo"`)
+	copySummary := flag.Bool("copy", false, "copy today's summary to the clipboard and exit")
+	listen := flag.String("listen", "", "address to serve the REST API on, e.g. 127.0.0.1:7272 (beyond loopback it needs api_token)")
+	kiosk := flag.Bool("kiosk", false, "run full-screen with big digits and no quit keys, for a dedicated desk timer")
+	gpioPin := flag.Int("gpio-pin", -1, "sysfs GPIO pin driven high during work sessions")
+	serialDev := flag.String("serial", "", "serial device the current phase is written to")
+	ledName := flag.String("led", "", "sysfs LED, e.g. a keyboard backlight, tinted by phase")
+	rgbDevice := flag.Int("openrgb-device", -1, "OpenRGB device tinted red during work and green during breaks")
+	flag.BoolVar(&dryRun, "dry-run", false, "log what notifications, indicators, telemetry and task trackers would do instead of doing it")
+	flag.StringVar(&textfilePath, "metrics-textfile", "", "file to write metrics to for node_exporter's textfile collector, e.g. /var/lib/node_exporter/pomodoro.prom")
+	flag.Parse()
+
+	// Indicators follow the daemon's timer too.
+	if *gpioPin >= 0 {
+		indicators = append(indicators, indicate(fmt.Sprintf("gpio %d", *gpioPin), indicator.GPIO(*gpioPin)))
+	}
+	if *serialDev != "" {
+		indicators = append(indicators, indicate(*serialDev, indicator.Serial(*serialDev)))
+	}
+	if *ledName != "" {
+		indicators = append(indicators, indicate("led "+*ledName, indicator.LED(*ledName)))
+	}
+	if *rgbDevice >= 0 {
+		indicators = append(indicators, indicate(fmt.Sprintf("openrgb device %d", *rgbDevice), indicator.OpenRGB(*rgbDevice)))
+	}
+
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *copySummary {
+		if err := copySummaryNow(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log.SetOutput(io.Discard)
+	logPath := os.Getenv("POMODORO_LOG")
+	if dryRun && logPath == "" {
+		// The dry run has to be logged somewhere the UI doesn't draw.
+		dir, err := dataDir()
+		if err == nil {
+			err = os.MkdirAll(dir, 0o700)
+		}
+		if err != nil {
+			fmt.Println("Uh oh, we encountered an error:", err)
+			os.Exit(1)
+		}
+		logPath = filepath.Join(dir, "dry-run.log")
+	}
+	if logPath != "" {
+		f, err := tea.LogToFile(logPath, "pomodoro")
+		if err != nil {
+			fmt.Println("Uh oh, we encountered an error:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+	}
+
+	jobs := newRunner()
+
+	cfg, err := loadConfig()
+	if err == nil {
+		err = cfg.override(*work, *brk)
+	}
+	if err == nil && *intervals != "" {
+		cfg.Intervals = *intervals
+	}
+	if err == nil && *deadlineAt != "" {
+		cfg.Deadline = *deadlineAt
+	}
+	var segments []segment
+	if err == nil && cfg.Intervals != "" {
+		segments, err = parseSequence(cfg.Intervals)
+	}
+	var due deadline
+	if err == nil && cfg.Deadline != "" {
+		due, err = parseDeadline(time.Now(), cfg.Deadline)
+	}
+	if err != nil {
+		fmt.Println("Uh oh, we encountered an error:", err)
+		os.Exit(1)
+	}
+	pomo := pomodoro.New(pomodoro.Config{
+		Work:              cfg.Work.Duration,
+		ShortBreak:        cfg.Break.Duration,
+		LongBreak:         cfg.LongBreak.Duration,
+		LongBreakInterval: cfg.LongBreakInterval,
+	})
+	if len(segments) > 0 {
+		pomo.Segment(segments[0].length, segments[0].rest())
+	}
+	durationFormat = cfg.TimeFormat
+	askPauseReason = cfg.AskPauseReason
+	askReview = cfg.AskReview
+	pauseReasons = cfg.reasonBindings()
+	if cfg.OTLPEndpoint != "" {
+		telemetry = otlp.New(cfg.OTLPEndpoint, "pomodoro")
+	}
+
+	store, err := openHistory()
+	if err != nil {
+		log.Printf("history disabled: %v", err)
+	}
+	var (
+		taskNames []string
+		today     []history.Session
+	)
+	taskSource := newTaskProvider(cfg, store)
+	if c, ok := taskSource.(*cachedTasks); ok {
+		// Init refreshes the cache without holding up the start.
+		taskNames = c.Cached()
+	} else if taskSource != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		if taskNames, err = taskSource.List(ctx); err != nil {
+			log.Printf("loading tasks: %v", err)
+		}
+		cancel()
+	}
+	if store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		if err := reconcileJournal(ctx, store); err != nil {
+			log.Printf("reconciling the session journal: %v", err)
+		}
+		now := time.Now()
+		if today, err = store.Between(ctx, startOfDay(now), now.Add(time.Second)); err != nil {
+			log.Printf("loading today's sessions: %v", err)
+		}
+		cancel()
+	}
+
+	caps := termcap.Detect()
+	// Lipgloss detects colors on its own; this settles terminals it would
+	// misjudge, such as CI logs emulating one.
+	switch {
+	case !caps.Color:
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case caps.TrueColor:
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	}
+
+	var notifiers []notifier
+	if cfg.Notifications {
+		n, err := notify.ByName(cfg.NotifyBackend, cfg.NotifyCommand)
+		if err != nil {
+			log.Printf("desktop notifications disabled: %v", err)
+		} else if dryRun {
+			notifiers = append(notifiers, dryNotifier{n})
+		} else {
+			notifiers = append(notifiers, n)
+		}
+
+		// Fall back to the terminal when the desktop is out of reach.
+		if cfg.TerminalAlerts && (err != nil || os.Getenv("SSH_CONNECTION") != "") {
+			notifiers = append(notifiers, notify.Terminal(os.Stdout, caps.OSC))
+		}
+	}
+
+	if cfg.Sound {
+		n, err := newSoundNotifier(cfg.SoundFile)
+		if err != nil {
+			log.Printf("sound disabled: %v", err)
+		} else {
+			notifiers = append(notifiers, n)
+		}
+	}
+
+	progressOpts := []progress.Option{
+		progress.WithGradient(cfg.Colors.ProgressStart, cfg.Colors.ProgressEnd),
+		progress.WithWidth(40),
+		progress.WithoutPercentage(),
+	}
+	if !caps.Unicode {
+		progressOpts = append(progressOpts, progress.WithFillCharacters('#', '.'))
+	}
+	if !caps.Color {
+		progressOpts = append(progressOpts, progress.WithColorProfile(termenv.Ascii))
+	}
+	// The daily goal gets a solid bar to set it apart from the timer's.
+	goalOpts := append(slices.Clip(progressOpts), progress.WithSolidFill(cfg.Colors.Border))
+
+	recent := &eventLog{}
+	bus := newEventBus(cfg, store, taskSource, newDispatcher(notifiers...))
+	bus.SubscribeAll("debug", recent.record)
+	if !caps.TTY {
+		subscribePlain(bus, os.Stdout)
+	}
+	queue := events.NewQueue(bus, eventTimeout)
+
 	m := model{
-		timer: timer.New(timeout),
-		progress: progress.New(progress.WithDefaultGradient(),
-			progress.WithWidth(40),
-			progress.WithoutPercentage()),
+		cfg:          cfg,
+		caps:         caps,
+		kiosk:        *kiosk,
+		pomo:         pomo,
+		timer:        timer.NewWithInterval(pomo.Length(), cfg.TickInterval.Duration),
+		progress:     progress.New(progressOpts...),
+		goalProgress: progress.New(goalOpts...),
 		keymap: keymap{
 			start: key.NewBinding(
 				key.WithKeys("s", " "),
@@ -199,13 +1027,156 @@ func main() {
 				key.WithKeys("w"),
 				key.WithHelp("w", "start work"),
 			),
+			pair: key.NewBinding(
+				key.WithKeys("g"),
+				key.WithHelp("g", "pair mode"),
+			),
+			guestToggle: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp("enter", "guest start/stop"),
+			),
+			guestReady: key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp("tab", "guest ready"),
+			),
+			park: key.NewBinding(
+				key.WithKeys("i"),
+				key.WithHelp("i", "park idea"),
+			),
+			tasks: key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp("t", "tasks"),
+			),
+			name: key.NewBinding(
+				key.WithKeys("n"),
+				key.WithHelp("n", "name session"),
+			),
+			toggleHelp: key.NewBinding(
+				key.WithKeys("?"),
+				key.WithHelp("?", "toggle help"),
+			),
+			bigClock: key.NewBinding(
+				key.WithKeys("b"),
+				key.WithHelp("b", "big clock"),
+			),
+			debug: key.NewBinding(
+				key.WithKeys("D"),
+				key.WithHelp("D", "debug snapshot"),
+			),
+			undo: key.NewBinding(
+				key.WithKeys("u"),
+				key.WithHelp("u", "undo"),
+			),
+			copyStatus: key.NewBinding(
+				key.WithKeys("c"),
+				key.WithHelp("c", "copy status"),
+			),
+			copyToday: key.NewBinding(
+				key.WithKeys("C"),
+				key.WithHelp("C", "copy today"),
+			),
+			parkUp: key.NewBinding(
+				key.WithKeys("up", "k"),
+				key.WithHelp("↑/k", "previous idea"),
+			),
+			parkDown: key.NewBinding(
+				key.WithKeys("down", "j"),
+				key.WithHelp("↓/j", "next idea"),
+			),
+			discard: key.NewBinding(
+				key.WithKeys("d"),
+				key.WithHelp("d", "discard idea"),
+			),
+			promote: key.NewBinding(
+				key.WithKeys("a"),
+				key.WithHelp("a", "make it a task"),
+			),
 		},
-		help: help.New(),
+		help:           help.New(),
+		capture:        newCaptureInput(),
+		intentionInput: newIntentionInput(),
+		tasks:          newTaskList(taskNames),
+		taskSource:     taskSource,
+		taskInput:      newTaskInput(),
+		history:        store,
+		intervals:      segments,
+		deadline:       due,
+		interrupts:     map[string]int{},
+		reviews:        map[string]int{},
+		jobs:           jobs,
+		queue:          queue,
+		recent:         recent,
+	}
+
+	if !caps.Unicode {
+		m.help.ShortSeparator = " | "
+	}
+
+	cfg.Keys.apply(&m.keymap)
+
+	m.pomo.SetCompleted(summarize(today).completed, time.Now())
+
+	if m.intervalMode() {
+		m.keymap.pauseTimer.SetHelp(m.keymap.pauseTimer.Help().Key, "next segment")
+		m.keymap.workTimer.SetHelp(m.keymap.workTimer.Help().Key, "restart")
+	}
+
+	if !m.kiosk {
+		state, err := loadState()
+		if err != nil {
+			log.Printf("restoring layout: %v", err)
+		}
+		m = m.restore(state)
 	}
 
 	m.keymap.stop.SetEnabled(false)
+	m.keymap.guestToggle.SetEnabled(false)
+	m.keymap.guestReady.SetEnabled(false)
+
+	var opts []tea.ProgramOption
+	if !caps.TTY {
+		// Redrawing the screen would flood a pipe or a log; the bus
+		// prints phase changes instead. Unlike tea.WithoutRenderer,
+		// this keeps the keyboard in raw mode.
+		opts = append(opts, tea.WithOutput(io.Discard))
+	}
+	if m.kiosk {
+		m = m.beginSession()
+		m.keymap.quit.SetEnabled(false)
+		// Kiosks always show the big clock.
+		m.keymap.bigClock.SetEnabled(false)
+		if caps.AltScreen {
+			opts = append(opts, tea.WithAltScreen())
+		}
+	}
+
+	p := tea.NewProgram(m, opts...)
+	if *listen != "" {
+		ln, err := serveAPI(*listen, cfg.APIToken, p)
+		if err != nil {
+			fmt.Println("Uh oh, we encountered an error:", err)
+			os.Exit(1)
+		}
+		defer ln.Close()
+	}
+	saveTitle(m.titleOutput())
+	final, err := p.Run()
+	restoreTitle(m.titleOutput())
+	if dryRun {
+		fmt.Println("Dry run logged to", logPath)
+	}
+	jobs.Stop()
+	closeQueue(queue)
+	if final, ok := final.(model); ok && !final.kiosk {
+		if err := saveState(final.state()); err != nil {
+			log.Printf("saving layout: %v", err)
+		}
+	}
+	if store != nil {
+		store.Close()
+	}
 
-	if _, err := tea.NewProgram(m).Run(); err != nil {
+	if err != nil {
 		fmt.Println("Uh oh, we encountered an error:", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// metricsInterval is how often the textfile is rewritten.
+const metricsInterval = time.Second * 15
+
+// textfilePath is where metrics are written for node_exporter's textfile
+// collector. Nothing is written when it's empty.
+var textfilePath string
+
+type metricsTickMsg struct{}
+
+func metricsTick() tea.Cmd {
+	return tea.Tick(metricsInterval, func(time.Time) tea.Msg {
+		return metricsTickMsg{}
+	})
+}
+
+// metrics renders the current state in the Prometheus text format.
+func (m model) metrics() string {
+	var b strings.Builder
+
+	b.WriteString("# HELP pomodoro_phase Current timer phase.\n")
+	b.WriteString("# TYPE pomodoro_phase gauge\n")
+	current := m.phase()
+	for _, phase := range []string{phaseIdle, phaseWork, phaseBreak, phasePaused, phaseDone} {
+		value := 0
+		if phase == current {
+			value = 1
+		}
+		fmt.Fprintf(&b, "pomodoro_phase{phase=%q} %d\n", phase, value)
+	}
+
+	b.WriteString("# HELP pomodoro_remaining_seconds Time left in the current session.\n")
+	b.WriteString("# TYPE pomodoro_remaining_seconds gauge\n")
+	fmt.Fprintf(&b, "pomodoro_remaining_seconds %g\n", m.timer.Timeout.Seconds())
+
+	b.WriteString("# HELP pomodoro_interruptions_total Work sessions paused, by reason.\n")
+	b.WriteString("# TYPE pomodoro_interruptions_total counter\n")
+	writeCounts(&b, "pomodoro_interruptions_total", "reason", m.interrupts)
+
+	b.WriteString("# HELP pomodoro_reviews_total Answers to the end-of-session review.\n")
+	b.WriteString("# TYPE pomodoro_reviews_total counter\n")
+	writeCounts(&b, "pomodoro_reviews_total", "answer", m.reviews)
+
+	b.WriteString("# HELP pomodoro_parked_ideas Ideas waiting in the parking lot.\n")
+	b.WriteString("# TYPE pomodoro_parked_ideas gauge\n")
+	fmt.Fprintf(&b, "pomodoro_parked_ideas %d\n", len(m.parked))
+
+	return b.String()
+}
+
+func writeCounts(b *strings.Builder, name, label string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", name, label, k, counts[k])
+	}
+}
+
+// writeMetrics snapshots the metrics and writes them in the background.
+func (m model) writeMetrics() tea.Cmd {
+	if textfilePath == "" {
+		return nil
+	}
+
+	data := m.metrics()
+	return m.jobs.Run("metrics", time.Second*5, func(context.Context) error {
+		return writeFileAtomic(textfilePath, []byte(data))
+	})
+}
+
+// writeFileAtomic replaces path in one step, so readers never see a half
+// written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
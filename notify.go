@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"charm/test/internal/notify"
+)
+
+const (
+	notifyRetries = 3
+	notifyBackoff = time.Second
+	notifyDedup   = time.Second * 30
+)
+
+// notifier delivers an alert through a single channel, such as a desktop
+// notification or a webhook.
+type notifier interface {
+	Notify(ctx context.Context, title, body string) error
+}
+
+// permanentError marks a notification failure that retrying won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// permanent wraps err so the dispatcher gives up on it right away.
+func permanent(err error) error {
+	return permanentError{err}
+}
+
+// suppressedError reports an alert held back because the desktop asked
+// for quiet, so the user can tell why nothing showed up.
+type suppressedError struct {
+	reason string
+}
+
+func (e suppressedError) Error() string { return "alert suppressed, " + e.reason }
+
+// dispatcher fans an alert out to every configured notifier at once,
+// retrying transient failures and dropping repeats of the same alert.
+// Alerts are suppressed while the system is in do-not-disturb or
+// presentation mode.
+type dispatcher struct {
+	notifiers []notifier
+	quiet     func() (string, bool)
+	backoff   time.Duration
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func newDispatcher(notifiers ...notifier) *dispatcher {
+	return &dispatcher{
+		notifiers: notifiers,
+		quiet:     notify.Quiet,
+		backoff:   notifyBackoff,
+		sent:      map[string]time.Time{},
+	}
+}
+
+// Send delivers the alert, unless the same one went out within the dedup
+// window. It returns once ctx is done even if a notifier ignores it.
+func (d *dispatcher) Send(ctx context.Context, title, body string) error {
+	if d == nil || len(d.notifiers) == 0 || d.duplicate(title+"\x00"+body) {
+		return nil
+	}
+	if reason, quiet := d.quiet(); quiet {
+		log.Printf("suppressed alert %q: %s", body, reason)
+		return suppressedError{reason}
+	}
+
+	errs := make(chan error, len(d.notifiers))
+	for _, n := range d.notifiers {
+		go func() {
+			errs <- d.deliver(ctx, n, title, body)
+		}()
+	}
+
+	var all []error
+	for range d.notifiers {
+		select {
+		case err := <-errs:
+			all = append(all, err)
+		case <-ctx.Done():
+			return errors.Join(append(all, ctx.Err())...)
+		}
+	}
+	return errors.Join(all...)
+}
+
+// duplicate reports whether the alert was already sent within the dedup
+// window, recording it otherwise.
+func (d *dispatcher) duplicate(alert string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for k, at := range d.sent {
+		if now.Sub(at) > notifyDedup {
+			delete(d.sent, k)
+		}
+	}
+
+	if _, ok := d.sent[alert]; ok {
+		return true
+	}
+	d.sent[alert] = now
+	return false
+}
+
+// deliver sends the alert through n, backing off between failed attempts.
+func (d *dispatcher) deliver(ctx context.Context, n notifier, title, body string) error {
+	var err error
+	for attempt := 0; attempt < notifyRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d.backoff * time.Duration(attempt)):
+			}
+		}
+
+		err = n.Notify(ctx, title, body)
+
+		var perm permanentError
+		if err == nil || errors.As(err, &perm) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeNotifier fails its first failures calls, and blocks until its
+// context is done when block is set.
+type fakeNotifier struct {
+	failures int
+	err      error
+	block    bool
+	calls    atomic.Int32
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, _, _ string) error {
+	call := int(n.calls.Add(1))
+	if n.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if call <= n.failures {
+		return n.err
+	}
+	return nil
+}
+
+// stuckNotifier ignores its context and never returns until released.
+type stuckNotifier struct {
+	release chan struct{}
+}
+
+func (n stuckNotifier) Notify(context.Context, string, string) error {
+	<-n.release
+	return nil
+}
+
+func testDispatcher(notifiers ...notifier) *dispatcher {
+	d := newDispatcher(notifiers...)
+	d.quiet = func() (string, bool) { return "", false }
+	d.backoff = time.Millisecond
+	return d
+}
+
+var errFlaky = errors.New("flaky")
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int32
+		wantErr   error
+	}{
+		{"delivered", 0, nil, 1, nil},
+		{"delivered on retry", 2, errFlaky, 3, nil},
+		{"gives up", 5, errFlaky, notifyRetries, errFlaky},
+		{"permanent", 5, permanent(errFlaky), 1, errFlaky},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &fakeNotifier{failures: tt.failures, err: tt.err}
+			err := testDispatcher(n).Send(context.Background(), "title", "body")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Send() = %v, want %v", err, tt.wantErr)
+			}
+			if got := n.calls.Load(); got != tt.wantCalls {
+				t.Errorf("Notify() called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDispatcherFansOut(t *testing.T) {
+	ok, failing := &fakeNotifier{}, &fakeNotifier{failures: 5, err: permanent(errFlaky)}
+	err := testDispatcher(ok, failing).Send(context.Background(), "title", "body")
+	if !errors.Is(err, errFlaky) {
+		t.Errorf("Send() = %v, want %v", err, errFlaky)
+	}
+	if ok.calls.Load() != 1 || failing.calls.Load() != 1 {
+		t.Errorf("Notify() called %d and %d times, want once each", ok.calls.Load(), failing.calls.Load())
+	}
+}
+
+func TestDispatcherDedup(t *testing.T) {
+	n := &fakeNotifier{}
+	d := testDispatcher(n)
+	ctx := context.Background()
+
+	d.Send(ctx, "Work session finished", "Time for a break.")
+	d.Send(ctx, "Work session finished", "Time for a break.")
+	if got := n.calls.Load(); got != 1 {
+		t.Errorf("repeated alert sent %d times, want once", got)
+	}
+
+	d.Send(ctx, "Break finished", "Back to work.")
+	if got := n.calls.Load(); got != 2 {
+		t.Errorf("different alert sent %d times in all, want 2", got)
+	}
+
+	// Past the window, the same alert goes out again.
+	d.mu.Lock()
+	for k := range d.sent {
+		d.sent[k] = time.Now().Add(-notifyDedup - time.Second)
+	}
+	d.mu.Unlock()
+	d.Send(ctx, "Work session finished", "Time for a break.")
+	if got := n.calls.Load(); got != 3 {
+		t.Errorf("alert after the window sent %d times in all, want 3", got)
+	}
+}
+
+func TestDispatcherQuiet(t *testing.T) {
+	n := &fakeNotifier{}
+	d := testDispatcher(n)
+	d.quiet = func() (string, bool) { return "presentation mode is on", true }
+
+	var suppressed suppressedError
+	if err := d.Send(context.Background(), "title", "body"); !errors.As(err, &suppressed) {
+		t.Errorf("Send() = %v, want the alert suppressed", err)
+	}
+	if got := n.calls.Load(); got != 0 {
+		t.Errorf("Notify() called %d times while quiet", got)
+	}
+}
+
+func TestDispatcherReturnsPromptly(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := []struct {
+		name string
+		n    notifier
+	}{
+		{"blocking", &fakeNotifier{block: true}},
+		{"ignoring its context", stuckNotifier{release}},
+		{"backing off", &fakeNotifier{failures: 5, err: errFlaky}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDispatcher(tt.n)
+			d.backoff = time.Hour
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+			defer cancel()
+			start := time.Now()
+			err := d.Send(ctx, "title", "body")
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Send() = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if took := time.Since(start); took > time.Second {
+				t.Errorf("Send() took %v past its deadline", took)
+			}
+		})
+	}
+}
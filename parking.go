@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	saveIdea = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "park"),
+	)
+	cancelIdea = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	)
+)
+
+func newCaptureInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Idea or distraction"
+	ti.CharLimit = 120
+	ti.Width = 60
+	return ti
+}
+
+// reviewing reports whether the parking lot is open for review, which is
+// only the case during breaks.
+func (m model) reviewing() bool {
+	return m.pomo.OnBreak() && len(m.parked) > 0
+}
+
+// updateCapture feeds keys to the quick-capture input until the idea is
+// parked or the capture is cancelled.
+func (m model) updateCapture(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, saveIdea):
+		if idea := strings.TrimSpace(m.capture.Value()); idea != "" {
+			m.parked = append(m.parked, idea)
+		}
+		fallthrough
+	case key.Matches(msg, cancelIdea):
+		m.capturing = false
+		m.capture.Reset()
+		m.capture.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.capture, cmd = m.capture.Update(msg)
+	return m, cmd
+}
+
+// updateReview moves through the parked ideas and discards them or turns
+// them into tasks.
+func (m model) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, m.keymap.parkUp):
+		if m.parkCursor > 0 {
+			m.parkCursor--
+		}
+	case key.Matches(msg, m.keymap.parkDown):
+		if m.parkCursor < len(m.parked)-1 {
+			m.parkCursor++
+		}
+	case key.Matches(msg, m.keymap.discard):
+		parked, cursor := slices.Clone(m.parked), m.parkCursor
+		m = m.pushUndo("discarding idea", func(m model) (model, tea.Cmd) {
+			m.parked, m.parkCursor = parked, cursor
+			return m, nil
+		})
+		m = m.unpark()
+	case key.Matches(msg, m.keymap.promote):
+		if !canAdd(m.taskSource) {
+			m.jobErr = errNoAdding
+			break
+		}
+		cmd = m.saveTask(m.parked[m.parkCursor])
+		m = m.unpark()
+	default:
+		return m, nil, false
+	}
+
+	return m, cmd, true
+}
+
+// unpark removes the idea under the cursor from the parking lot.
+func (m model) unpark() model {
+	m.parked = append(m.parked[:m.parkCursor:m.parkCursor], m.parked[m.parkCursor+1:]...)
+	if m.parkCursor >= len(m.parked) && m.parkCursor > 0 {
+		m.parkCursor--
+	}
+	return m
+}
+
+// parkingView shows the capture input, the parked ideas during breaks, or
+// just how many are waiting while working.
+func (m model) parkingView() string {
+	switch {
+	case m.capturing:
+		return "\n" + m.capture.View() + "\n" +
+			m.help.ShortHelpView([]key.Binding{saveIdea, cancelIdea})
+	case m.reviewing():
+		var b strings.Builder
+		b.WriteString("\nParking lot:")
+		for i, idea := range m.parked {
+			cursor := "  "
+			if i == m.parkCursor {
+				cursor = "> "
+			}
+			b.WriteString("\n" + cursor + idea)
+		}
+		return b.String()
+	case len(m.parked) > 0:
+		return fmt.Sprintf("\n%d idea(s) parked for the break", len(m.parked))
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/timer"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/events"
+	"charm/test/internal/indicator"
+	"charm/test/internal/otlp"
+	"charm/test/internal/pomodoro"
+)
+
+const (
+	phaseIdle   = "idle"
+	phaseWork   = "work"
+	phaseBreak  = "break"
+	phasePaused = "paused"
+	phaseDone   = "done"
+)
+
+// indicators mirror phase changes on physical devices.
+var indicators []indicator.Indicator
+
+// telemetry exports phase changes to an OpenTelemetry collector when an
+// endpoint is configured.
+var telemetry *otlp.Exporter
+
+// phase names the state of the timer's state machine.
+func (m model) phase() string {
+	return phaseOf(m.pomo.State())
+}
+
+func phaseOf(s pomodoro.State) string {
+	switch s {
+	case pomodoro.Done:
+		return phaseDone
+	case pomodoro.Idle:
+		return phaseIdle
+	case pomodoro.Paused:
+		return phasePaused
+	case pomodoro.ShortBreak, pomodoro.LongBreak:
+		return phaseBreak
+	default:
+		return phaseWork
+	}
+}
+
+// elapsed is the share of the current phase that has passed. It's exact
+// rather than in the countdown's whole seconds, so short tick intervals
+// move the progress bar smoothly.
+func (m model) elapsed() float64 {
+	length := m.pomo.Length()
+	return float64(length-m.pomo.Remaining(time.Now())) / float64(length)
+}
+
+// newTimer returns a countdown of length ticking at the configured
+// interval.
+func (m model) newTimer(length time.Duration) timer.Model {
+	return timer.NewWithInterval(length, m.cfg.TickInterval.Duration)
+}
+
+// phaseChanged announces the new phase, refreshes the metrics textfile
+// and saves the state.
+func (m model) phaseChanged(from, to string) (model, tea.Cmd) {
+	m = m.emit(events.PhaseChanged{At: time.Now(), From: from, To: to, Remaining: m.timer.Timeout})
+	return m, tea.Batch(m.writeMetrics(), m.saveTimer())
+}
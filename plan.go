@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// segment is a single block of a plan, such as a 25 minute work session.
+type segment struct {
+	label  string
+	length time.Duration
+}
+
+// segmentKinds maps the suffixes accepted in a plan to segment labels.
+var segmentKinds = map[byte]string{
+	'w': "work",
+	'b': "break",
+}
+
+// segmentUnits are the units of labelled blocks like "90s:plank".
+var segmentUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+}
+
+// Limits of a plan, keeping typos like "1000x(...)" from building plans
+// nobody could follow.
+const (
+	maxRepeat   = 100
+	maxSegments = 1000
+	maxSegment  = 24 * time.Hour
+)
+
+// rest reports whether the segment is a break rather than an activity.
+func (s segment) rest() bool {
+	return s.label == "break" || s.label == "rest"
+}
+
+// parseSequence parses a plan like "4x(25w 5b) 30b" into its segments.
+// Blocks are minutes followed by a kind, or a length with a unit and a
+// label like "90s:plank". Groups repeat with "Nx(...)".
+func parseSequence(s string) ([]segment, error) {
+	p := &sequenceParser{src: s}
+
+	segments, err := p.sequence()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("plan is empty")
+	}
+
+	return segments, nil
+}
+
+type sequenceParser struct {
+	src string
+	pos int
+}
+
+func (p *sequenceParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("plan: column %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *sequenceParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// sequence parses blocks and groups until the input or the enclosing
+// group ends.
+func (p *sequenceParser) sequence() ([]segment, error) {
+	var segments []segment
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] == ')' {
+			return segments, nil
+		}
+
+		items, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		if len(segments)+len(items) > maxSegments {
+			return nil, p.errorf("plan has more than %d blocks", maxSegments)
+		}
+		segments = append(segments, items...)
+	}
+}
+
+func (p *sequenceParser) item() ([]segment, error) {
+	n, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("missing kind after %d", n)
+	}
+
+	if p.src[p.pos] == 'x' {
+		if n > maxRepeat {
+			return nil, p.errorf("repeat %dx is over %dx", n, maxRepeat)
+		}
+		p.pos++
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '(' {
+			return nil, p.errorf("expected ( after %dx", n)
+		}
+		p.pos++
+
+		group, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		if len(group)*n > maxSegments {
+			return nil, p.errorf("plan has more than %d blocks", maxSegments)
+		}
+
+		var segments []segment
+		for i := 0; i < n; i++ {
+			segments = append(segments, group...)
+		}
+		return segments, nil
+	}
+
+	if unit, ok := segmentUnits[p.src[p.pos]]; ok && p.pos+1 < len(p.src) && p.src[p.pos+1] == ':' {
+		p.pos += 2
+		label := p.label()
+		if label == "" {
+			return nil, p.errorf("missing label after %d%c:", n, p.src[p.pos-2])
+		}
+		if err := p.checkLength(n, unit); err != nil {
+			return nil, err
+		}
+		return []segment{{label: label, length: time.Duration(n) * unit}}, nil
+	}
+
+	label, ok := segmentKinds[p.src[p.pos]]
+	if !ok {
+		return nil, p.errorf("unknown kind %q", p.src[p.pos])
+	}
+	p.pos++
+	if err := p.checkLength(n, time.Minute); err != nil {
+		return nil, err
+	}
+
+	return []segment{{label: label, length: time.Duration(n) * time.Minute}}, nil
+}
+
+// checkLength rejects blocks of n units longer than a day, before their
+// length could overflow.
+func (p *sequenceParser) checkLength(n int, unit time.Duration) error {
+	if n > int(maxSegment/unit) {
+		return p.errorf("block is longer than a day")
+	}
+	return nil
+}
+
+func (p *sequenceParser) label() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *sequenceParser) number() (int, error) {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos < len(p.src) {
+			return 0, p.errorf("expected a number, got %q", p.src[p.pos])
+		}
+		return 0, p.errorf("expected a number")
+	}
+
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil || n <= 0 {
+		return 0, p.errorf("invalid number %q", p.src[start:p.pos])
+	}
+	return n, nil
+}
+
+// runPlan implements the "plan" subcommand.
+func runPlan(args []string) error {
+	if len(args) == 0 || args[0] != "simulate" {
+		return errors.New(`usage: pomodoro plan simulate [--start 09:00] [--stop 17:00] "4x(25w 5b) 30b"`)
+	}
+
+	fs := flag.NewFlagSet("plan simulate", flag.ContinueOnError)
+	start := fs.String("start", "", "wall-clock start time (HH:MM), defaults to now")
+	stop := fs.String("stop", "", "hard stop (HH:MM) the plan has to fit before")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	segments, err := parseSequence(strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Truncate(time.Minute)
+	at := now
+	if *start != "" {
+		if at, err = clockTime(now, *start); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	var work time.Duration
+	for _, seg := range segments {
+		end := at.Add(seg.length)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", at.Format("15:04"), end.Format("15:04"), seg.label, formatDuration(seg.length, formatCompact))
+		if !seg.rest() {
+			work += seg.length
+		}
+		at = end
+	}
+	w.Flush()
+
+	fmt.Printf("\nEnds at %s with %s of focus time.\n", at.Format("15:04"), formatDuration(work, formatCompact))
+
+	if *stop != "" {
+		deadline, err := clockTime(now, *stop)
+		if err != nil {
+			return err
+		}
+		if at.After(deadline) {
+			fmt.Printf("Overruns the %s stop by %s.\n", *stop, formatDuration(at.Sub(deadline), formatCompact))
+		} else {
+			fmt.Printf("Fits before %s with %s to spare.\n", *stop, formatDuration(deadline.Sub(at), formatCompact))
+		}
+	}
+
+	return nil
+}
+
+// clockTime returns the given HH:MM time on the same day as day.
+func clockTime(day time.Time, hhmm string) (time.Time, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected HH:MM", hhmm)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSequence(t *testing.T) {
+	work := segment{"work", 25 * time.Minute}
+	short := segment{"break", 5 * time.Minute}
+	tests := []struct {
+		src  string
+		want []segment
+	}{
+		{"25w", []segment{work}},
+		{"  25w\t5b ", []segment{work, short}},
+		{"2x(25w 5b) 30b", []segment{work, short, work, short, {"break", 30 * time.Minute}}},
+		{"2x (1w)", []segment{{"work", time.Minute}, {"work", time.Minute}}},
+		{"2x(2x(1b))", slices.Repeat([]segment{{"break", time.Minute}}, 4)},
+		{"90s:plank 30s:rest", []segment{{"plank", 90 * time.Second}, {"rest", 30 * time.Second}}},
+		{"1h:deep_work-2", []segment{{"deep_work-2", time.Hour}}},
+		{"1440w", []segment{{"work", maxSegment}}},
+		{"86400s:long", []segment{{"long", maxSegment}}},
+		{"100x(10x(1w))", slices.Repeat([]segment{{"work", time.Minute}}, maxSegments)},
+	}
+	for _, tt := range tests {
+		got, err := parseSequence(tt.src)
+		if err != nil {
+			t.Errorf("parseSequence(%q) = %v", tt.src, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseSequence(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseSequenceErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		// Limits.
+		{"101x(1w)", "repeat 101x is over 100x"},
+		{"100x(11x(1w))", "more than 1000 blocks"},
+		{"1000x(1w) 1b", "repeat 1000x"},
+		{"10x(100x(1w)) 1b", "more than 1000 blocks"},
+		{"1441w", "longer than a day"},
+		{"25h:focus", "longer than a day"},
+		{"86401s:plank", "longer than a day"},
+		{"99999999999999999999w", "invalid number"},
+
+		// Malformed plans.
+		{"", "plan is empty"},
+		{"   ", "plan is empty"},
+		{"2x()", "plan is empty"},
+		{"0w", "invalid number"},
+		{"w", "expected a number"},
+		{"25", "missing kind after 25"},
+		{"25q", "unknown kind 'q'"},
+		{"5m:", "missing label"},
+		{"5m: plank", "missing label"},
+		{"4x 25w", "expected ( after 4x"},
+		{"4x(25w", "missing )"},
+		{"25w)", "unexpected ')'"},
+		{"x(", "expected a number, got 'x'"},
+		{"25w,5b", "expected a number, got ','"},
+	}
+	for _, tt := range tests {
+		_, err := parseSequence(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseSequence(%q) = %v, want an error containing %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func FuzzParsePlan(f *testing.F) {
+	for _, seed := range []string{
+		"25w",
+		"4x(25w 5b) 30b",
+		"3x(90s:plank 30s:rest) 2m:stretch",
+		"2x(2x(1w 1b))",
+		"1h:focus",
+		"100x(10x(1w))",
+		"",
+		"x(",
+		"4x(25w",
+		"25",
+		"25q",
+		"99999999999999999999w",
+		"0w",
+		"5m:",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		segments, err := parseSequence(src)
+		if err != nil {
+			return
+		}
+		if len(segments) == 0 || len(segments) > maxSegments {
+			t.Fatalf("parseSequence(%q) = %d blocks", src, len(segments))
+		}
+		for _, s := range segments {
+			if s.length <= 0 || s.length > maxSegment {
+				t.Fatalf("parseSequence(%q) has a block of %v", src, s.length)
+			}
+			if s.label == "" {
+				t.Fatalf("parseSequence(%q) has a block without a label", src)
+			}
+		}
+	})
+}
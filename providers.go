@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"charm/test/internal/gitlab"
+	"charm/test/internal/history"
+	"charm/test/internal/linear"
+	"charm/test/internal/taskfile"
+	"charm/test/internal/trello"
+)
+
+// taskProvider is where the task list comes from. Tasks are known by
+// name only.
+type taskProvider interface {
+	// List returns the names of the open tasks.
+	List(ctx context.Context) ([]string, error)
+	// Complete marks the named task done, taking it off the list.
+	Complete(ctx context.Context, name string) error
+	// Annotate attaches a note to the named task, where the service keeps
+	// notes.
+	Annotate(ctx context.Context, name, text string) error
+}
+
+// taskAdder is implemented by providers tasks can be added to.
+type taskAdder interface {
+	Add(ctx context.Context, name string) error
+}
+
+// newTaskProvider returns the configured task provider: a task file or
+// an issue tracker, falling back to the list kept in the history. It
+// returns nil without either. The tasks of issue trackers are cached.
+func newTaskProvider(cfg config, store *history.Store) taskProvider {
+	var (
+		p       taskProvider
+		service string
+	)
+	switch {
+	case cfg.TasksFile != "":
+		if dryRun {
+			return dryTasks{fileTasks{taskfile.Open(cfg.TasksFile)}, "tasks file"}
+		}
+		return fileTasks{taskfile.Open(cfg.TasksFile)}
+	case cfg.Trello.List != "":
+		t := cfg.Trello
+		p, service = trello.New(t.Key, t.Token, t.List, t.DoneList), "trello"
+	case cfg.Linear.APIKey != "":
+		p, service = linear.New(cfg.Linear.APIKey), "linear"
+	case cfg.GitLab.Token != "":
+		g := cfg.GitLab
+		p, service = gitlab.New(g.URL, g.Token, g.Project), "gitlab"
+	case cfg.GitLab.ClientID != "":
+		g := cfg.GitLab
+		p, service = gitlab.NewOAuth(g.URL, oauthSource(cfg, "gitlab").Token, g.Project), "gitlab"
+	case store != nil:
+		return historyTasks{store}
+	default:
+		return nil
+	}
+
+	// A cache would take pretended changes for real ones.
+	if dryRun {
+		return dryTasks{p, service}
+	}
+
+	cached, err := newCachedTasks(p, service, cfg.TaskCacheTTL.Duration)
+	if err != nil {
+		log.Printf("task cache disabled: %v", err)
+		return p
+	}
+	return cached
+}
+
+// canAdd reports whether tasks can be added to p.
+func canAdd(p taskProvider) bool {
+	switch w := p.(type) {
+	case *cachedTasks:
+		p = w.p
+	case dryTasks:
+		p = w.p
+	}
+	_, ok := p.(taskAdder)
+	return ok
+}
+
+// fileTasks are the tasks of a todo.txt or Markdown checklist.
+type fileTasks struct {
+	f *taskfile.File
+}
+
+func (t fileTasks) List(context.Context) ([]string, error) {
+	tasks, err := t.f.Tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, task := range tasks {
+		if !task.Done {
+			names = append(names, task.Name)
+		}
+	}
+	return names, nil
+}
+
+func (t fileTasks) Add(_ context.Context, name string) error {
+	return t.f.Add(name)
+}
+
+func (t fileTasks) Complete(_ context.Context, name string) error {
+	return t.f.Complete(name)
+}
+
+// Annotate does nothing; task files have no room for notes.
+func (t fileTasks) Annotate(context.Context, string, string) error {
+	return nil
+}
+
+// historyTasks is the task list kept in the history database.
+type historyTasks struct {
+	store *history.Store
+}
+
+func (t historyTasks) List(ctx context.Context) ([]string, error) {
+	return t.store.Tasks(ctx)
+}
+
+func (t historyTasks) Add(ctx context.Context, name string) error {
+	return t.store.AddTask(ctx, name)
+}
+
+// Complete removes the task from the list. Sessions keep its name.
+func (t historyTasks) Complete(ctx context.Context, name string) error {
+	return t.store.RemoveTask(ctx, name)
+}
+
+// Annotate does nothing; the history already records the sessions spent
+// on each task.
+func (t historyTasks) Annotate(context.Context, string, string) error {
+	return nil
+}
+
+// errNoAdding is shown when adding to a provider that doesn't take new
+// tasks.
+var errNoAdding = errors.New("this task list doesn't take new tasks")
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/pomodoro"
+)
+
+var (
+	resumeSession = key.NewBinding(
+		key.WithKeys("y", "enter"),
+		key.WithHelp("y", "resume"),
+	)
+	discardSession = key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "start fresh"),
+	)
+)
+
+// saveTimer saves the state, so a crash doesn't lose the running session.
+// It's called on every phase change; the deadline covers the time between.
+func (m model) saveTimer() tea.Cmd {
+	if m.kiosk || m.askingResume {
+		return nil
+	}
+
+	state := m.state()
+	return m.jobs.Run("state", time.Second*5, func(context.Context) error {
+		return saveState(state)
+	})
+}
+
+// updateResume answers the prompt to resume the last session.
+func (m model) updateResume(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, resumeSession):
+		m.askingResume = false
+		return m.resumeTimer(*m.resume)
+	case key.Matches(msg, discardSession):
+		m.askingResume = false
+		m.resume = nil
+		return m, m.saveTimer()
+	}
+	return m, nil
+}
+
+// resumeTimer picks the session up where it was left. Quitting recorded it
+// as aborted, or the journal as interrupted, so that record is dropped
+// again.
+func (m model) resumeTimer(t timerState) (tea.Model, tea.Cmd) {
+	m.pomo.Restore(t.Machine)
+	m.sessionStart = t.SessionStart
+	m.sessionLength = m.pomo.Length()
+	m.intention, m.task, m.segment = t.Intention, t.Task, t.Segment
+	m.resume = nil
+	m = m.sessionStarted()
+
+	var historyCmd tea.Cmd
+	if m.history != nil && !t.SessionStart.IsZero() {
+		store := m.history
+		historyCmd = m.jobs.Run("history", time.Second*5, func(ctx context.Context) error {
+			return store.Delete(ctx, t.SessionStart)
+		})
+	}
+
+	left := m.pomo.Remaining(time.Now())
+	m.timer = m.newTimer((left + time.Second - 1).Truncate(time.Second))
+	timerCmd := m.timer.Stop()
+	if m.pomo.Running() {
+		timerCmd = m.timer.Start()
+	}
+
+	return m, tea.Batch(m.progress.SetPercent(m.elapsed()), historyCmd, timerCmd)
+}
+
+// resumeView asks whether to resume the last session.
+func (m model) resumeView() string {
+	t := m.resume
+	what := "work session"
+	switch t.Machine.Kind {
+	case pomodoro.ShortBreak:
+		what = "break"
+	case pomodoro.LongBreak:
+		what = "long break"
+	}
+
+	var p pomodoro.Machine
+	p.Restore(t.Machine)
+	left := formatDuration(p.Remaining(time.Now()).Round(time.Second), durationFormat)
+	if t.Machine.State == pomodoro.Paused {
+		left += ", paused"
+	}
+
+	return fmt.Sprintf("\nResume the %s with %s left?\n", what, left) +
+		m.help.ShortHelpView([]key.Binding{resumeSession, discardSession})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/events"
+)
+
+// askReview controls whether finishing a work session asks whether the
+// planned work got done.
+var askReview = true
+
+const reviewPromptTimeout = time.Second * 10
+
+// reviewAnswers are the replies to the end-of-pomodoro review.
+var reviewAnswers = []key.Binding{
+	key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "yes")),
+	key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "no")),
+	key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "partial")),
+}
+
+// reviewExpiredMsg dismisses an unanswered review prompt.
+type reviewExpiredMsg struct {
+	id int
+}
+
+// promptReview opens the review prompt for the work session started at
+// start and schedules its dismissal.
+func (m model) promptReview(start time.Time) (model, tea.Cmd) {
+	m.reviewID++
+	m.askingReview = true
+	m.reviewStart = start
+
+	id := m.reviewID
+	return m, tea.Tick(reviewPromptTimeout, func(time.Time) tea.Msg {
+		return reviewExpiredMsg{id}
+	})
+}
+
+// updateReviewAnswer records the answer to the review prompt with the
+// session.
+func (m model) updateReviewAnswer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, skipReason) {
+		m.askingReview = false
+		return m, nil
+	}
+
+	for _, answer := range reviewAnswers {
+		if key.Matches(msg, answer) {
+			m.reviews[answer.Help().Desc]++
+			m.askingReview = false
+			if m.reviewStart.IsZero() {
+				return m, nil
+			}
+			return m.emit(events.SessionReviewed{Start: m.reviewStart, Answer: answer.Help().Desc}), nil
+		}
+	}
+
+	return m, nil
+}
+
+// reviewView summarizes how often the planned work got finished.
+func (m model) reviewView() string {
+	total := m.reviews["yes"] + m.reviews["no"] + m.reviews["partial"]
+	if total == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\nFinished as planned: %d of %d (%d partial)",
+		m.reviews["yes"], total, m.reviews["partial"])
+}
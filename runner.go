@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobDoneMsg reports the outcome of a background job.
+type jobDoneMsg struct {
+	name string
+	err  error
+}
+
+// runner executes the model's I/O (hooks, HTTP, audio, storage) in
+// tea.Cmd goroutines so Update never blocks. Every job runs with a
+// timeout and is cancelled once the app quits.
+type runner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+func newRunner() *runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &runner{ctx: ctx, cancel: cancel}
+}
+
+// Run returns a command executing fn in the background and reporting its
+// result as a jobDoneMsg.
+func (r *runner) Run(name string, timeout time.Duration, fn func(ctx context.Context) error) tea.Cmd {
+	return func() tea.Msg {
+		if !r.begin() {
+			return jobDoneMsg{name: name, err: context.Canceled}
+		}
+		defer r.wg.Done()
+
+		ctx, cancel := context.WithTimeout(r.ctx, timeout)
+		defer cancel()
+
+		return jobDoneMsg{name: name, err: fn(ctx)}
+	}
+}
+
+// begin registers a job unless the runner was already cancelled.
+func (r *runner) begin() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ctx.Err() != nil {
+		return false
+	}
+	r.wg.Add(1)
+	return true
+}
+
+// Cancel signals every running job to stop without waiting for them.
+func (r *runner) Cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cancel()
+}
+
+// Stop cancels every running job and waits until all of them returned.
+func (r *runner) Stop() {
+	r.Cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.uber.org/goleak"
+
+	"charm/test/internal/events"
+)
+
+// run executes a job's command the way bubbletea does, in its own
+// goroutine, and returns its message once it's done.
+func run(cmd tea.Cmd) <-chan tea.Msg {
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	return done
+}
+
+// blocking returns a job waiting for its context, closing started once it
+// runs.
+func blocking(started chan<- struct{}) func(context.Context) error {
+	return func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	r := newRunner()
+	want := errors.New("failed")
+	msg := r.Run("job", time.Second, func(context.Context) error { return want })()
+	if got := msg.(jobDoneMsg); got.name != "job" || got.err != want {
+		t.Errorf("Run() = %+v, want job failing with %v", got, want)
+	}
+	r.Stop()
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	r := newRunner()
+	defer r.Stop()
+	msg := r.Run("job", time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})()
+	if err := msg.(jobDoneMsg).err; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRunnerCancel(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	r := newRunner()
+	started := make(chan struct{})
+	cmd := r.Run("job", time.Hour, blocking(started))
+	done := run(cmd)
+	<-started
+
+	r.Cancel()
+	select {
+	case msg := <-done:
+		if err := msg.(jobDoneMsg).err; !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled job = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("job didn't stop once cancelled")
+	}
+	r.Stop()
+}
+
+func TestRunnerStopWaits(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	r := newRunner()
+	started := make(chan struct{})
+	var finished atomic.Bool
+	cmd := r.Run("job", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		// Cleaning up after being cancelled takes a while.
+		time.Sleep(time.Millisecond * 50)
+		finished.Store(true)
+		return ctx.Err()
+	})
+	done := run(cmd)
+	<-started
+
+	r.Stop()
+	if !finished.Load() {
+		t.Error("Stop() returned before the running job")
+	}
+	<-done
+}
+
+func TestRunnerAfterStop(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	r := newRunner()
+	r.Stop()
+
+	var called bool
+	msg := r.Run("job", time.Second, func(context.Context) error {
+		called = true
+		return nil
+	})()
+	if called {
+		t.Error("job ran after Stop()")
+	}
+	if err := msg.(jobDoneMsg).err; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() after Stop() = %v, want %v", err, context.Canceled)
+	}
+}
+
+// TestShutdown quits the way main does while a subscriber hangs, checking
+// that quitting doesn't wait for it and leaves no goroutine behind.
+func TestShutdown(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	bus := events.NewBus()
+	started := make(chan struct{})
+	bus.SubscribeAll("hanging", func(ctx context.Context, _ events.Event) error {
+		select {
+		case <-started:
+		default:
+			close(started)
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	jobs := newRunner()
+	queue := events.NewQueue(bus, time.Hour)
+
+	m := model{jobs: jobs, queue: queue}
+	for range 3 {
+		m = m.emit(events.GoalReached{})
+	}
+	_, cmd := m.publish()
+	done := run(cmd)
+	<-started
+
+	jobs.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err := queue.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	<-done
+
+	if err := <-queue.Add(events.GoalReached{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Add() after Close() = %v, want %v", err, context.Canceled)
+	}
+}
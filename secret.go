@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"charm/test/internal/keyring"
+)
+
+// secretPrefix marks config values kept in the keyring, like
+// token = "keyring:gitlab".
+const secretPrefix = "keyring:"
+
+// openKeyring returns the keyring. Its fallback file lives in the config
+// directory and the file's key in the data directory.
+func openKeyring() (*keyring.Keyring, error) {
+	cfgDir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.New(filepath.Join(cfgDir, "secrets.enc"), filepath.Join(dataDir, "secrets.key")), nil
+}
+
+// credential is a setting holding a secret.
+type credential struct {
+	setting string
+	value   *string
+}
+
+// credentials returns the settings holding secrets.
+func (c *config) credentials() []credential {
+	return []credential{
+		{"trello.key", &c.Trello.Key},
+		{"trello.token", &c.Trello.Token},
+		{"linear.api_key", &c.Linear.APIKey},
+		{"gitlab.token", &c.GitLab.Token},
+		{"google.client_secret", &c.Google.ClientSecret},
+		{"api_token", &c.APIToken},
+	}
+}
+
+// redacted returns the config with its secrets blanked out.
+func (c config) redacted() config {
+	for _, s := range c.credentials() {
+		if *s.value != "" {
+			*s.value = "REDACTED"
+		}
+	}
+	return c
+}
+
+// resolveSecrets replaces the credentials referring to the keyring with
+// the secrets stored there.
+func (c *config) resolveSecrets() error {
+	var ring *keyring.Keyring
+	for _, s := range c.credentials() {
+		name, ok := strings.CutPrefix(*s.value, secretPrefix)
+		if !ok {
+			continue
+		}
+		if ring == nil {
+			var err error
+			if ring, err = openKeyring(); err != nil {
+				return err
+			}
+		}
+
+		secret, err := ring.Get(name)
+		if errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("config: %s: no secret %q, store it with: pomodoro secret set %s", s.setting, name, name)
+		}
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", s.setting, err)
+		}
+		*s.value = secret
+	}
+	return nil
+}
+
+// runSecret implements the "secret" subcommand.
+func runSecret(args []string) error {
+	fs := flag.NewFlagSet("secret", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 || (fs.Arg(0) != "set" && fs.Arg(0) != "delete") {
+		return errors.New("usage: pomodoro secret set|delete NAME")
+	}
+	name := fs.Arg(1)
+	if !keyring.ValidName(name) {
+		return fmt.Errorf("%q can't name a secret; use letters, digits, dots, dashes and underscores", name)
+	}
+
+	ring, err := openKeyring()
+	if err != nil {
+		return err
+	}
+	if fs.Arg(0) == "delete" {
+		return ring.Delete(name)
+	}
+
+	secret, err := readSecret(name)
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return errors.New("no secret given")
+	}
+	if err := ring.Set(name, secret); err != nil {
+		return err
+	}
+	fmt.Printf("Stored %s in the %s. Refer to it in config.toml as %q.\n", name, ring.Backend(), secretPrefix+name)
+	return nil
+}
+
+// readSecret reads a secret from the terminal without echoing it, or the
+// first line of stdin when piped.
+func readSecret(name string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintf(os.Stderr, "Secret for %s: ", name)
+		secret, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(secret)), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
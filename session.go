@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"charm/test/internal/events"
+	"charm/test/internal/history"
+	"charm/test/internal/pomodoro"
+)
+
+// openHistory opens the session history in the data directory.
+func openHistory() (*history.Store, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	return history.Open(filepath.Join(dir, "history.db"))
+}
+
+// beginSession marks the start of the session the timer is counting down.
+func (m model) beginSession() model {
+	m.sessionStart = time.Now()
+	m.sessionLength = m.pomo.Length()
+	return m.sessionStarted()
+}
+
+// sessionStarted announces the running session.
+func (m model) sessionStarted() model {
+	if m.sessionStart.IsZero() {
+		return m
+	}
+
+	s := m.session()
+	return m.emit(events.SessionStarted{Start: s.Start, Phase: s.Phase, Intention: s.Intention, Task: s.Task})
+}
+
+// session describes the running session for the history.
+func (m model) session() history.Session {
+	session := history.Session{Start: m.sessionStart, Phase: phaseWork}
+	switch {
+	case m.intervalMode():
+		session.Phase = m.intervals[m.segment].label
+	case m.pomo.Kind() == pomodoro.LongBreak:
+		session.Phase = "long break"
+	case m.pomo.OnBreak():
+		session.Phase = phaseBreak
+	default:
+		session.Intention = m.intention
+		session.Task = m.task
+	}
+	return session
+}
+
+// endSession ends the running session, if any.
+func (m model) endSession(completed bool) model {
+	if m.sessionStart.IsZero() {
+		return m
+	}
+
+	session := m.session()
+	session.End = time.Now()
+	session.Duration = m.sessionLength - m.timer.Timeout
+	session.Completed = completed
+	if completed && session.Intention != "" {
+		m.intention = ""
+	}
+	m.sessionStart = time.Time{}
+	return m.emit(events.SessionEnded{Session: session})
+}
+
+// emit queues an event, published once the update is done.
+func (m model) emit(e events.Event) model {
+	m.pending = append(slices.Clip(m.pending), e)
+	return m
+}
+
+// publish hands the events emitted during the update to their subscribers
+// in the background. They're queued right away, so subscribers see them in
+// order; the commands only wait for the outcome.
+func (m model) publish() (model, tea.Cmd) {
+	cmds := make([]tea.Cmd, len(m.pending))
+	for i, e := range m.pending {
+		result := m.queue.Add(e)
+		cmds[i] = m.jobs.Run(e.Name(), eventTimeout, func(ctx context.Context) error {
+			select {
+			case err := <-result:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	m.pending = nil
+	return m, tea.Batch(cmds...)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"charm/test/internal/audio"
+)
+
+// soundNotifier plays a sound as an alert channel, so sounds share the
+// dispatcher's background delivery and do-not-disturb handling.
+type soundNotifier struct {
+	player audio.Player
+	path   string
+}
+
+func (s soundNotifier) Notify(ctx context.Context, _, _ string) error {
+	err := s.player.Play(ctx, s.path)
+	if err != nil {
+		// Retrying won't make a missing file or codec appear.
+		return permanent(err)
+	}
+	return nil
+}
+
+// newSoundNotifier plays file, or the built-in chime when file is empty,
+// through a player able to decode it.
+func newSoundNotifier(file string) (notifier, error) {
+	if file == "" {
+		var err error
+		if file, err = audio.ChimeFile(); err != nil {
+			return nil, err
+		}
+	}
+	format, err := audio.Sniff(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return soundNotifier{player: audio.Detect(os.Stdout, format), path: file}, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"charm/test/internal/history"
+)
+
+// defaultStandupTemplate renders the standup as two bullet lists, ready to
+// paste into chat. It's replaced by standup.tmpl in the config directory.
+const defaultStandupTemplate = `*{{.Previous.Label}}*
+{{range .Previous.Items}}- {{.Name}} ({{.Pomodoros}} pomodoros, {{.Focus}})
+{{else}}- Nothing recorded
+{{end}}
+*{{.Today.Label}}*
+{{range .Today.Items}}- {{.Name}} ({{.Pomodoros}} pomodoros, {{.Focus}})
+{{else}}- Nothing recorded yet
+{{end}}`
+
+// standupDay is what was worked on during a day.
+type standupDay struct {
+	Label string
+	Date  time.Time
+	Items []standupItem
+}
+
+// standupItem is a task or intention worked on.
+type standupItem struct {
+	Name      string
+	Pomodoros int
+	Focus     string
+
+	focus time.Duration
+}
+
+// standupItems groups work sessions by task, or by intention for sessions
+// without a task, in the order they were first worked on.
+func standupItems(sessions []history.Session) []standupItem {
+	var items []standupItem
+	index := map[string]int{}
+	for _, s := range sessions {
+		name := s.Task
+		if name == "" {
+			name = s.Intention
+		}
+		if s.Phase != phaseWork || name == "" {
+			continue
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(items)
+			index[name] = i
+			items = append(items, standupItem{Name: name})
+		}
+		if s.Completed {
+			items[i].Pomodoros++
+		}
+		items[i].focus += s.Duration
+		items[i].Focus = formatDuration(items[i].focus, formatCompact)
+	}
+	return items
+}
+
+// runStandup implements the "standup" subcommand. Yesterday is the last
+// day with work recorded in the past week, so Mondays report on Friday.
+func runStandup(args []string) error {
+	fs := flag.NewFlagSet("standup", flag.ContinueOnError)
+	tmplPath := fs.String("template", "", "template file, defaults to standup.tmpl in the config directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: pomodoro standup [--template file]")
+	}
+
+	tmpl, err := standupTemplate(*tmplPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	now := time.Now()
+	today := startOfDay(now)
+	sessions, err := store.Between(ctx, today.AddDate(0, 0, -7), now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+
+	byDay := map[time.Time][]history.Session{}
+	for _, s := range sessions {
+		day := startOfDay(s.Start)
+		byDay[day] = append(byDay[day], s)
+	}
+
+	previous := standupDay{Label: "Yesterday", Date: today.AddDate(0, 0, -1)}
+	for day := today.AddDate(0, 0, -1); !day.Before(today.AddDate(0, 0, -7)); day = day.AddDate(0, 0, -1) {
+		if items := standupItems(byDay[day]); len(items) > 0 {
+			previous.Date, previous.Items = day, items
+			if day.Before(today.AddDate(0, 0, -1)) {
+				previous.Label = day.Format("Monday")
+			}
+			break
+		}
+	}
+
+	return tmpl.Execute(os.Stdout, struct {
+		Previous standupDay
+		Today    standupDay
+	}{
+		Previous: previous,
+		Today:    standupDay{Label: "Today", Date: today, Items: standupItems(byDay[today])},
+	})
+}
+
+// standupTemplate loads the template at path, or standup.tmpl from the
+// config directory when path is empty and the file exists.
+func standupTemplate(path string) (*template.Template, error) {
+	text := defaultStandupTemplate
+	if path == "" {
+		if dir, err := configDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(dir, "standup.tmpl")); err == nil {
+				text = string(data)
+			}
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("standup").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("standup template: %w", err)
+	}
+	return tmpl, nil
+}
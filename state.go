@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"charm/test/internal/pomodoro"
+)
+
+// Views the app can be left in.
+const (
+	viewTimer = "timer"
+	viewTasks = "tasks"
+)
+
+// resumeWindow is how long after quitting a session is offered to be
+// resumed.
+const resumeWindow = time.Hour * 12
+
+// uiState is the layout and the running session remembered across
+// restarts. There is no layout scale or theme to switch at runtime yet:
+// the big clock is the only size, and colors come from the config file.
+type uiState struct {
+	View     string      `json:"view"`
+	HideHelp bool        `json:"hide_help"`
+	BigClock bool        `json:"big_clock"`
+	Timer    *timerState `json:"timer,omitempty"`
+}
+
+// timerState is a started session, saved to resume it after a restart.
+type timerState struct {
+	SavedAt      time.Time         `json:"saved_at"`
+	Machine      pomodoro.Snapshot `json:"machine"`
+	SessionStart time.Time         `json:"session_start"`
+	Intention    string            `json:"intention,omitempty"`
+	Task         string            `json:"task,omitempty"`
+	Segment      int               `json:"segment,omitempty"`
+}
+
+func statePath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// loadState reads the remembered state. A missing file yields the default
+// layout.
+func loadState() (uiState, error) {
+	state := uiState{View: viewTimer}
+
+	path, err := statePath()
+	if err != nil {
+		return state, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	return state, json.Unmarshal(data, &state)
+}
+
+// saveState remembers the state for the next start.
+func saveState(state uiState) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// state returns the model's current layout and session.
+func (m model) state() uiState {
+	state := uiState{View: viewTimer, HideHelp: m.hideHelp, BigClock: m.bigClock, Timer: m.timerState()}
+	if m.choosingTask && !m.addingTask {
+		state.View = viewTasks
+	}
+	return state
+}
+
+// timerState returns the started session, if any, or the one offered for
+// resuming while the prompt is open or once quit.
+func (m model) timerState() *timerState {
+	if m.resume != nil {
+		return m.resume
+	}
+	switch m.pomo.State() {
+	case pomodoro.Idle, pomodoro.Done:
+		return nil
+	}
+
+	return &timerState{
+		SavedAt:      time.Now(),
+		Machine:      m.pomo.Snapshot(),
+		SessionStart: m.sessionStart,
+		Intention:    m.intention,
+		Task:         m.task,
+		Segment:      m.segment,
+	}
+}
+
+// restore applies a remembered layout and offers to resume the session
+// that was running, if it's recent enough.
+func (m model) restore(state uiState) model {
+	m.hideHelp = state.HideHelp
+	m.bigClock = state.BigClock
+	m.choosingTask = state.View == viewTasks
+
+	if t := state.Timer; t != nil && time.Since(t.SavedAt) < resumeWindow &&
+		t.Machine.Custom == m.intervalMode() && t.Segment < max(len(m.intervals), 1) {
+		m.resume = t
+		m.askingResume = true
+	}
+	return m
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+
+	"charm/test/internal/history"
+	"charm/test/internal/termcap"
+)
+
+// summary aggregates the work sessions of a period.
+type summary struct {
+	completed int
+	total     int
+	focus     time.Duration
+	// reviews counts the review answers by answer.
+	reviews map[string]int
+}
+
+func summarize(sessions []history.Session) summary {
+	s := summary{reviews: map[string]int{}}
+	for _, session := range sessions {
+		if session.Phase != phaseWork {
+			continue
+		}
+		s.total++
+		s.focus += session.Duration
+		if session.Completed {
+			s.completed++
+		}
+		if session.Review != "" {
+			s.reviews[session.Review]++
+		}
+	}
+	return s
+}
+
+func (s summary) average() string {
+	if s.total == 0 {
+		return "-"
+	}
+	return formatDuration(s.focus/time.Duration(s.total), formatCompact)
+}
+
+func (s summary) rate() string {
+	if s.total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", s.completed*100/s.total)
+}
+
+// planned is the share of the reviewed sessions whose planned work got
+// done, counting partly done ones as half.
+func (s summary) planned() string {
+	reviewed := s.reviews["yes"] + s.reviews["partial"] + s.reviews["no"]
+	if reviewed == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", (s.reviews["yes"]*2+s.reviews["partial"])*50/reviewed)
+}
+
+// topReasons lists the most frequent pause reasons with their counts.
+func topReasons(counts map[string]int) string {
+	reasons := rankReasons(counts)
+	if len(reasons) == 0 {
+		return "-"
+	}
+	return strings.Join(reasons[:min(len(reasons), 3)], ", ")
+}
+
+// byTask summarizes the sessions of every task, in the order the tasks
+// were first worked on.
+func byTask(sessions []history.Session) ([]string, map[string]summary) {
+	var (
+		tasks   []string
+		grouped = map[string][]history.Session{}
+	)
+	for _, session := range sessions {
+		if session.Task == "" {
+			continue
+		}
+		if _, ok := grouped[session.Task]; !ok {
+			tasks = append(tasks, session.Task)
+		}
+		grouped[session.Task] = append(grouped[session.Task], session)
+	}
+
+	summaries := make(map[string]summary, len(tasks))
+	for _, task := range tasks {
+		summaries[task] = summarize(grouped[task])
+	}
+	return tasks, summaries
+}
+
+// startOfDay returns midnight of t's day.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns midnight of the Monday of t's week.
+func startOfWeek(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// statsTable returns an empty table in the style of the stats, with the
+// first column as labels.
+func statsTable() *table.Table {
+	headerStyle := lipgloss.NewStyle().Bold(true).Padding(0, 1)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+	return table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color(defaultConfig().Colors.Border))).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow || col == 0 {
+				return headerStyle
+			}
+			return cellStyle.Align(lipgloss.Right)
+		})
+}
+
+// runStats implements the "stats" subcommand.
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	showHeatmap := fs.Bool("heatmap", false, "show completed pomodoros per day of the last months")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: pomodoro stats [--heatmap]")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	store, err := openHistory()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	now := time.Now()
+	if *showHeatmap {
+		first := startOfWeek(now).AddDate(0, 0, -7*(heatmapWeeks-1))
+		sessions, err := store.Between(ctx, first, now.Add(time.Second))
+		if err != nil {
+			return err
+		}
+		fmt.Println(heatmap(sessions, now, termcap.Detect().Unicode))
+		return nil
+	}
+
+	week, err := store.Between(ctx, startOfWeek(now), now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+
+	var today []history.Session
+	for _, session := range week {
+		if !session.Start.Before(startOfDay(now)) {
+			today = append(today, session)
+		}
+	}
+	d, w := summarize(today), summarize(week)
+
+	dayReasons, err := store.Interruptions(ctx, startOfDay(now), now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	weekReasons, err := store.Interruptions(ctx, startOfWeek(now), now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+
+	t := statsTable().
+		Headers("", "Today", "This week").
+		Row("Pomodoros", fmt.Sprint(d.completed), fmt.Sprint(w.completed)).
+		Row("Focus time", formatDuration(d.focus, formatCompact), formatDuration(w.focus, formatCompact)).
+		Row("Average session", d.average(), w.average()).
+		Row("Completion rate", d.rate(), w.rate()).
+		Row("Done as planned", d.planned(), w.planned()).
+		Row("Top interruptions", topReasons(dayReasons), topReasons(weekReasons))
+
+	fmt.Println(t)
+
+	if tasks, summaries := byTask(week); len(tasks) > 0 {
+		t := statsTable().Headers("This week", "Pomodoros", "Completion rate", "Done as planned")
+		for _, task := range tasks {
+			s := summaries[task]
+			t.Row(task, fmt.Sprint(s.completed), s.rate(), s.planned())
+		}
+		fmt.Println(t)
+	}
+
+	all, err := store.Between(ctx, time.Unix(0, 0), now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	goal := max(cfg.DailyGoal, 1)
+	current, best := streaks(all, now, goal)
+	fmt.Printf("Streak: %d day(s), best %d day(s) with at least %d pomodoro(s)\n", current, best, goal)
+	return nil
+}
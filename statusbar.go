@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"charm/test/internal/pomodoro"
+)
+
+// barIcons mark the phase in status bars.
+var barIcons = map[string]string{
+	phaseIdle:   "⏹",
+	phaseWork:   "🍅",
+	phaseBreak:  "☕",
+	phasePaused: "⏸",
+	phaseDone:   "✔",
+}
+
+// barStatus is the timer's state as printed for status bars. The text,
+// tooltip, class and percentage fields are the ones waybar's custom
+// modules read.
+type barStatus struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+	Phase      string `json:"phase"`
+	Icon       string `json:"icon"`
+	Remaining  int    `json:"remaining_seconds"`
+}
+
+// runStatus implements the "status" subcommand. It asks the daemon, if
+// one is running, and reads the state the app saved otherwise.
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	format := fs.String("format", "plain", "output format: plain or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || (*format != "plain" && *format != "json") {
+		return errors.New("usage: pomodoro status [--format plain|json]")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	reply, err := askDaemon(daemonStatus)
+	if errors.Is(err, errNoDaemon) {
+		reply, err = savedStatus(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	left := formatDuration(reply.Remaining, cfg.TimeFormat)
+	status := barStatus{
+		Text:      barIcons[reply.Phase] + " " + left,
+		Tooltip:   fmt.Sprintf("%s, %s left, %d pomodoros today", reply.Kind, left, reply.Completed),
+		Class:     reply.Phase,
+		Phase:     reply.Phase,
+		Icon:      barIcons[reply.Phase],
+		Remaining: int((reply.Remaining + time.Second - 1) / time.Second),
+	}
+	if reply.Length > 0 {
+		status.Percentage = int(100 * (reply.Length - reply.Remaining) / reply.Length)
+	}
+
+	if *format == "json" {
+		return json.NewEncoder(os.Stdout).Encode(status)
+	}
+	fmt.Println(status.Text)
+	return nil
+}
+
+// savedStatus describes the session the app saved, or an idle timer when
+// there's none to resume.
+func savedStatus(cfg config) (daemonReply, error) {
+	pomo := pomodoro.New(pomodoro.Config{
+		Work:              cfg.Work.Duration,
+		ShortBreak:        cfg.Break.Duration,
+		LongBreak:         cfg.LongBreak.Duration,
+		LongBreakInterval: cfg.LongBreakInterval,
+	})
+
+	state, err := loadState()
+	if err != nil {
+		return daemonReply{}, err
+	}
+	now := time.Now()
+	if t := state.Timer; t != nil && now.Sub(t.SavedAt) < resumeWindow {
+		pomo.Restore(t.Machine)
+	}
+
+	reply := daemonReply{
+		State:     pomo.State().String(),
+		Kind:      kindName(pomo.Kind()),
+		Phase:     phaseOf(pomo.State()),
+		Remaining: pomo.Remaining(now),
+		Length:    pomo.Length(),
+	}
+	if store, err := openHistory(); err == nil {
+		defer store.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		today, err := store.Between(ctx, startOfDay(now), now.Add(time.Second))
+		if err != nil {
+			return reply, err
+		}
+		reply.Completed = summarize(today).completed
+	}
+	return reply, nil
+}
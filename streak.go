@@ -0,0 +1,40 @@
+package main
+
+import (
+	"time"
+
+	"charm/test/internal/history"
+)
+
+// streaks returns the current and best number of consecutive days with at
+// least goal completed pomodoros. A today that isn't done yet doesn't
+// break the current streak.
+func streaks(sessions []history.Session, now time.Time, goal int) (current, best int) {
+	counts := map[string]int{}
+	var first time.Time
+	for _, s := range sessions {
+		if s.Phase != phaseWork || !s.Completed {
+			continue
+		}
+		counts[s.Start.Format(time.DateOnly)]++
+		if first.IsZero() || s.Start.Before(first) {
+			first = s.Start
+		}
+	}
+	if first.IsZero() {
+		return 0, 0
+	}
+
+	today := startOfDay(now)
+	run := 0
+	for day := startOfDay(first); !day.After(today); day = day.AddDate(0, 0, 1) {
+		if counts[day.Format(time.DateOnly)] >= goal {
+			run++
+			best = max(best, run)
+		} else if !day.Equal(today) {
+			run = 0
+		}
+	}
+
+	return run, best
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"charm/test/internal/events"
+	"charm/test/internal/history"
+	"charm/test/internal/indicator"
+	"charm/test/internal/otlp"
+)
+
+// eventTimeout bounds how long the subscribers of an event may take,
+// retried notifications included.
+const eventTimeout = time.Second * 30
+
+// closeTimeout bounds how long quitting waits for the events still queued.
+const closeTimeout = time.Second * 5
+
+// closeQueue publishes the events still queued when quitting, giving up on
+// them after closeTimeout.
+func closeQueue(q *events.Queue) {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	if err := q.Close(ctx); err != nil {
+		log.Printf("dropping queued events: %v", err)
+	}
+}
+
+// newEventBus subscribes the configured storage, notifications and
+// integrations to the timer's events.
+func newEventBus(cfg config, store *history.Store, tasks taskProvider, alerts *dispatcher) *events.Bus {
+	bus := events.NewBus()
+	if store != nil {
+		subscribeHistory(bus, store)
+	}
+	if cfg.Ledger != "" {
+		subscribeLedger(bus, cfg.Ledger)
+	}
+	if tasks != nil {
+		subscribeTasks(bus, tasks, store)
+	}
+	if alerts != nil {
+		events.Subscribe(bus, "notification", func(ctx context.Context, e events.PhaseEnded) error {
+			return alerts.Send(ctx, e.Title, e.Body)
+		})
+	}
+	if len(indicators) > 0 {
+		subscribeIndicators(bus, indicators)
+	}
+	if telemetry != nil {
+		subscribeTelemetry(bus, telemetry)
+	}
+	return bus
+}
+
+// subscribeHistory records sessions in the history. Their starts are
+// journaled too, so sessions cut short by a crash are recovered.
+func subscribeHistory(bus *events.Bus, store *history.Store) {
+	events.Subscribe(bus, "journal", func(_ context.Context, e events.SessionStarted) error {
+		return appendJournal(journalEntry{
+			Event:     journalStart,
+			Start:     e.Start,
+			Phase:     e.Phase,
+			Intention: e.Intention,
+			Task:      e.Task,
+		})
+	})
+	events.Subscribe(bus, "history", func(ctx context.Context, e events.SessionEnded) error {
+		if err := store.Add(ctx, e.Session); err != nil {
+			return err
+		}
+		return appendJournal(journalEntry{Event: journalEnd, Start: e.Session.Start})
+	})
+	events.Subscribe(bus, "history", func(ctx context.Context, e events.SessionReviewed) error {
+		return store.SetReview(ctx, e.Start, e.Answer)
+	})
+	events.Subscribe(bus, "history", func(ctx context.Context, e events.Interrupted) error {
+		return store.AddInterruption(ctx, e.At, e.Start, e.Reason)
+	})
+}
+
+func subscribeLedger(bus *events.Bus, path string) {
+	events.Subscribe(bus, "ledger", func(_ context.Context, e events.SessionEnded) error {
+		return appendLedger(path, e.Session)
+	})
+}
+
+// subscribeTasks notes on the task of a completed work session how many
+// pomodoros were spent on it. Subscribed after the history, the count
+// includes the session.
+func subscribeTasks(bus *events.Bus, p taskProvider, store *history.Store) {
+	events.Subscribe(bus, "tasks", func(ctx context.Context, e events.SessionEnded) error {
+		session := e.Session
+		if session.Task == "" || !session.Completed {
+			return nil
+		}
+
+		minutes := int(session.Duration.Round(time.Minute).Minutes())
+		text := fmt.Sprintf("Pomodoro finished (%d min)", minutes)
+		if store != nil {
+			n, err := store.Pomodoros(ctx, session.Task)
+			if err != nil {
+				return err
+			}
+			text = fmt.Sprintf("Pomodoro #%d finished (%d min)", n, minutes)
+		}
+		return p.Annotate(ctx, session.Task, text)
+	})
+}
+
+// subscribeIndicators shows every new phase on the indicators.
+func subscribeIndicators(bus *events.Bus, inds []indicator.Indicator) {
+	events.Subscribe(bus, "indicator", func(ctx context.Context, e events.PhaseChanged) error {
+		var errs []error
+		for _, ind := range inds {
+			errs = append(errs, ind.Show(ctx, e.To))
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// subscribePlain prints phase changes and alerts as lines of text, for
+// output that isn't a terminal, such as a pipe or a CI log.
+func subscribePlain(bus *events.Bus, w io.Writer) {
+	events.Subscribe(bus, "plain output", func(_ context.Context, e events.PhaseChanged) error {
+		_, err := fmt.Fprintf(w, "%s %s, %s left\n", e.At.Format(time.TimeOnly), e.To, formatDuration(e.Remaining, formatClock))
+		return err
+	})
+	events.Subscribe(bus, "plain output", func(_ context.Context, e events.PhaseEnded) error {
+		_, err := fmt.Fprintf(w, "%s %s %s\n", e.At.Format(time.TimeOnly), e.Title, e.Body)
+		return err
+	})
+}
+
+// subscribeTelemetry exports phase changes and reached goals.
+func subscribeTelemetry(bus *events.Bus, exp *otlp.Exporter) {
+	emit := func(ctx context.Context, at time.Time, name string, attrs map[string]string) error {
+		if dryRun {
+			log.Printf("dry run: telemetry exports %s %v", name, attrs)
+			return nil
+		}
+		return exp.Emit(ctx, at, name, attrs)
+	}
+
+	events.Subscribe(bus, "telemetry", func(ctx context.Context, e events.PhaseChanged) error {
+		return emit(ctx, e.At, "pomodoro.phase_changed", map[string]string{
+			"pomodoro.phase":          e.To,
+			"pomodoro.previous_phase": e.From,
+			"pomodoro.remaining":      e.Remaining.String(),
+		})
+	})
+	events.Subscribe(bus, "telemetry", func(ctx context.Context, e events.GoalReached) error {
+		return emit(ctx, e.At, "pomodoro.goal_reached", map[string]string{
+			"pomodoro.goal": fmt.Sprint(e.Goal),
+		})
+	})
+}
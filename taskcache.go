@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// taskCache is the last task list fetched from a remote provider, with
+// the changes made while it was out of reach.
+type taskCache struct {
+	FetchedAt time.Time  `json:"fetched_at"`
+	Names     []string   `json:"names"`
+	Completed []string   `json:"completed,omitempty"`
+	Notes     []taskNote `json:"notes,omitempty"`
+}
+
+type taskNote struct {
+	Task string `json:"task"`
+	Text string `json:"text"`
+}
+
+// cachedTasks keeps a remote provider's tasks on disk, so tasks can be
+// picked and completed offline. Changes made offline are sent once the
+// provider answers again.
+type cachedTasks struct {
+	p    taskProvider
+	path string
+	ttl  time.Duration
+
+	mu sync.Mutex
+}
+
+// newCachedTasks caches the tasks of p, a provider for service, for ttl.
+func newCachedTasks(p taskProvider, service string, ttl time.Duration) (*cachedTasks, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "tasks-"+service+".json")
+	return &cachedTasks{p: p, path: path, ttl: ttl}, nil
+}
+
+// offline reports whether err means the provider couldn't be reached, as
+// opposed to it turning the request down.
+func offline(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *cachedTasks) load() taskCache {
+	var cache taskCache
+	if data, err := os.ReadFile(c.path); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+func (c *cachedTasks) save(cache taskCache) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o600)
+}
+
+// Cached returns the cached tasks without asking the provider.
+func (c *cachedTasks) Cached() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.load().Names
+}
+
+// List returns the cached tasks while they are fresh. Otherwise it sends
+// the changes made offline and fetches the tasks again, falling back to
+// the cache when the provider is out of reach.
+func (c *cachedTasks) List(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cache := c.load()
+	if time.Since(cache.FetchedAt) < c.ttl && len(cache.Completed)+len(cache.Notes) == 0 {
+		return cache.Names, nil
+	}
+
+	err := c.sync(ctx, &cache)
+	var names []string
+	if err == nil {
+		names, err = c.p.List(ctx)
+	}
+	if offline(err) && !cache.FetchedAt.IsZero() {
+		return cache.Names, c.save(cache)
+	}
+	if err != nil {
+		c.save(cache)
+		return nil, err
+	}
+
+	cache.Names, cache.FetchedAt = names, time.Now()
+	return names, c.save(cache)
+}
+
+// sync sends the changes made offline, dropping each once it went
+// through. Changes the provider turns down are dropped too, since
+// retrying won't help.
+func (c *cachedTasks) sync(ctx context.Context, cache *taskCache) error {
+	for len(cache.Notes) > 0 {
+		n := cache.Notes[0]
+		if err := c.p.Annotate(ctx, n.Task, n.Text); offline(err) {
+			return err
+		}
+		cache.Notes = cache.Notes[1:]
+	}
+	for len(cache.Completed) > 0 {
+		if err := c.p.Complete(ctx, cache.Completed[0]); offline(err) {
+			return err
+		}
+		cache.Completed = cache.Completed[1:]
+	}
+	return nil
+}
+
+// Complete completes the task, or takes it off the cached list to be
+// completed once the provider answers again.
+func (c *cachedTasks) Complete(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.p.Complete(ctx, name)
+	if err != nil && !offline(err) {
+		return err
+	}
+
+	cache := c.load()
+	cache.Names = slices.DeleteFunc(cache.Names, func(n string) bool { return n == name })
+	if err != nil {
+		cache.Completed = append(cache.Completed, name)
+	}
+	return c.save(cache)
+}
+
+// Annotate annotates the task, or keeps the note to send later.
+func (c *cachedTasks) Annotate(ctx context.Context, name, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.p.Annotate(ctx, name, text)
+	if err == nil || !offline(err) {
+		return err
+	}
+
+	cache := c.load()
+	cache.Notes = append(cache.Notes, taskNote{Task: name, Text: text})
+	return c.save(cache)
+}
+
+// Add adds the task to the provider, which has to be reachable for it.
+// The cache is refreshed with the next listing.
+func (c *cachedTasks) Add(ctx context.Context, name string) error {
+	a, ok := c.p.(taskAdder)
+	if !ok {
+		return errNoAdding
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := a.Add(ctx, name); err != nil {
+		return err
+	}
+	cache := c.load()
+	cache.FetchedAt = cache.FetchedAt.Add(-c.ttl)
+	return c.save(cache)
+}
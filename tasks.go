@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	selectTask = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "work on"),
+	)
+	addTask = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add task"),
+	)
+	closeTasks = key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "close"),
+	)
+	completeTask = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "done"),
+	)
+	confirmTask = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "add"),
+	)
+)
+
+// taskDoneDelay is how long a task marked done stays undoable before the
+// provider is told.
+const taskDoneDelay = time.Second * 30
+
+// task is an entry of the task list.
+type task string
+
+func (t task) FilterValue() string { return string(t) }
+func (t task) Title() string       { return string(t) }
+func (t task) Description() string { return "" }
+
+func newTaskList(names []string) list.Model {
+	items := make([]list.Item, len(names))
+	for i, name := range names {
+		items[i] = task(name)
+	}
+
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+
+	l := list.New(items, delegate, 60, 12)
+	l.Title = "Tasks"
+	l.SetShowStatusBar(false)
+	l.DisableQuitKeybindings()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{selectTask, addTask, completeTask, closeTasks}
+	}
+	return l
+}
+
+// tasksMsg carries the tasks listed by the task provider.
+type tasksMsg struct {
+	names []string
+	err   error
+}
+
+// loadTasks lists the provider's tasks in the background.
+func (m model) loadTasks() tea.Cmd {
+	p := m.taskSource
+	if p == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		names, err := p.List(ctx)
+		return tasksMsg{names: names, err: err}
+	}
+}
+
+// updateTaskItems replaces the task list with the listed tasks.
+func (m model) updateTaskItems(msg tasksMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.jobErr = fmt.Errorf("loading tasks failed: %w", msg.err)
+		return m, nil
+	}
+
+	// Tasks marked done but not yet sent to the provider stay hidden.
+	var items []list.Item
+	for _, name := range msg.names {
+		if !slices.Contains(m.doneTasks, name) {
+			items = append(items, task(name))
+		}
+	}
+	return m, m.tasks.SetItems(items)
+}
+
+// openTasks opens the task list, picking up changes made to the tasks in
+// the meantime.
+func (m model) openTasks() (tea.Model, tea.Cmd) {
+	m.choosingTask = true
+	return m, m.loadTasks()
+}
+
+func newTaskInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "New task"
+	ti.CharLimit = 120
+	ti.Width = 60
+	return ti
+}
+
+// updateTasks drives the task list until a task is picked or the list is
+// closed.
+func (m model) updateTasks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.addingTask {
+		return m.updateTaskInput(msg)
+	}
+
+	// While filtering, enter and esc belong to the filter.
+	if m.tasks.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.tasks, cmd = m.tasks.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, selectTask):
+		if t, ok := m.tasks.SelectedItem().(task); ok {
+			m.task = string(t)
+		}
+		m.choosingTask = false
+		return m, nil
+	case key.Matches(msg, addTask):
+		if !canAdd(m.taskSource) {
+			m.jobErr = errNoAdding
+			return m, nil
+		}
+		m.addingTask = true
+		return m, m.taskInput.Focus()
+	case key.Matches(msg, completeTask):
+		t, ok := m.tasks.SelectedItem().(task)
+		if !ok {
+			return m, nil
+		}
+		return m.completeTask(string(t))
+	case key.Matches(msg, closeTasks) && m.tasks.FilterState() == list.Unfiltered:
+		m.choosingTask = false
+		return m, nil
+	case key.Matches(msg, m.keymap.quit):
+		return m.quit()
+	}
+
+	var cmd tea.Cmd
+	m.tasks, cmd = m.tasks.Update(msg)
+	return m, cmd
+}
+
+// updateTaskInput edits the name of a new task and adds it to the list.
+func (m model) updateTaskInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, confirmTask):
+		name := strings.TrimSpace(m.taskInput.Value())
+		if name != "" && !m.hasTask(name) {
+			n := len(m.tasks.Items())
+			cmd = tea.Batch(m.tasks.InsertItem(n, task(name)), m.saveTask(name))
+			m.tasks.Select(n)
+		}
+	case key.Matches(msg, cancelIdea):
+	default:
+		m.taskInput, cmd = m.taskInput.Update(msg)
+		return m, cmd
+	}
+
+	m.addingTask = false
+	m.taskInput.Reset()
+	m.taskInput.Blur()
+	return m, cmd
+}
+
+func (m model) hasTask(name string) bool {
+	for _, item := range m.tasks.Items() {
+		if string(item.(task)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// saveTask adds a new task to the provider, then lists the tasks again
+// to pick up the name the provider gave it.
+func (m model) saveTask(name string) tea.Cmd {
+	p, ok := m.taskSource.(taskAdder)
+	if !ok {
+		return nil
+	}
+
+	return tea.Sequence(m.jobs.Run("tasks", time.Second*10, func(ctx context.Context) error {
+		return p.Add(ctx, name)
+	}), m.loadTasks())
+}
+
+// taskDueMsg tells the provider a task is done once it can no longer be
+// undone.
+type taskDueMsg struct {
+	name string
+}
+
+// completeTask takes the selected task off the list. The provider is told
+// after taskDoneDelay, or on quitting, unless it was undone by then.
+func (m model) completeTask(name string) (tea.Model, tea.Cmd) {
+	index, active := m.tasks.Index(), m.task == name
+	m.tasks.RemoveItem(index)
+	if active {
+		m.task = ""
+	}
+	m.doneTasks = append(slices.Clip(m.doneTasks), name)
+
+	m = m.pushUndo(undoCompleteDesc(name), func(m model) (model, tea.Cmd) {
+		m.doneTasks = slices.DeleteFunc(slices.Clone(m.doneTasks), func(n string) bool { return n == name })
+		index = min(index, len(m.tasks.Items()))
+		cmd := m.tasks.InsertItem(index, task(name))
+		m.tasks.Select(index)
+		if active && m.task == "" {
+			m.task = name
+		}
+		return m, cmd
+	})
+
+	m, toastCmd := m.showToast(fmt.Sprintf("Done: %s (%s to undo)", name, m.keymap.undo.Help().Key))
+	return m, tea.Batch(toastCmd, tea.Tick(taskDoneDelay, func(time.Time) tea.Msg {
+		return taskDueMsg{name}
+	}))
+}
+
+func undoCompleteDesc(name string) string {
+	return "completing " + name
+}
+
+// updateTaskDue tells the provider a task is done, unless completing it
+// was undone in the meantime.
+func (m model) updateTaskDue(msg taskDueMsg) (tea.Model, tea.Cmd) {
+	if !slices.Contains(m.doneTasks, msg.name) {
+		return m, nil
+	}
+	m.doneTasks = slices.DeleteFunc(slices.Clone(m.doneTasks), func(n string) bool { return n == msg.name })
+	m = m.dropUndo(undoCompleteDesc(msg.name))
+	return m, m.finishTasks(msg.name)
+}
+
+// finishTasks marks tasks done with the provider.
+func (m model) finishTasks(names ...string) tea.Cmd {
+	p := m.taskSource
+	if p == nil || len(names) == 0 {
+		return nil
+	}
+
+	return m.jobs.Run("tasks", time.Second*10, func(ctx context.Context) error {
+		var errs []error
+		for _, name := range names {
+			errs = append(errs, p.Complete(ctx, name))
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// taskView shows the active task next to the countdown.
+func (m model) taskView() string {
+	if m.task == "" {
+		return ""
+	}
+
+	sep := " · "
+	if !m.caps.Unicode {
+		sep = " - "
+	}
+	return lipgloss.NewStyle().Faint(true).Render(sep + m.task)
+}
+
+// tasksView shows the task list, or the input for a new task.
+func (m model) tasksView() string {
+	if !m.choosingTask {
+		return ""
+	}
+	if m.addingTask {
+		return "\n" + m.taskInput.View() + "\n" +
+			m.help.ShortHelpView([]key.Binding{confirmTask, cancelIdea})
+	}
+	return "\n" + m.tasks.View()
+}
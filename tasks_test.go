@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// fakeTasks records the tasks marked done.
+type fakeTasks struct {
+	mu   sync.Mutex
+	done []string
+}
+
+func (p *fakeTasks) List(context.Context) ([]string, error) { return nil, nil }
+
+func (p *fakeTasks) Complete(_ context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = append(p.done, name)
+	return nil
+}
+
+func (p *fakeTasks) Annotate(context.Context, string, string) error { return nil }
+
+func taskModel(p taskProvider, names ...string) model {
+	m := model{tasks: newTaskList(names), taskSource: p, jobs: newRunner()}
+	m.keymap.undo = key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "undo"))
+	return m
+}
+
+func taskNames(l list.Model) []string {
+	var names []string
+	for _, item := range l.Items() {
+		names = append(names, string(item.(task)))
+	}
+	return names
+}
+
+func TestCompleteTaskUndo(t *testing.T) {
+	p := &fakeTasks{}
+	m := taskModel(p, "a", "b", "c")
+	m.tasks.Select(1)
+	m.task = "b"
+
+	next, _ := m.completeTask("b")
+	m = next.(model)
+	if got := taskNames(m.tasks); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("tasks after completing = %q", got)
+	}
+	if m.task != "" {
+		t.Errorf("active task = %q, want none", m.task)
+	}
+
+	next, _ = m.undoLast()
+	m = next.(model)
+	if got := taskNames(m.tasks); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("tasks after undoing = %q", got)
+	}
+	if m.task != "b" {
+		t.Errorf("active task after undoing = %q, want b", m.task)
+	}
+
+	// The delayed completion finds nothing left to do.
+	if _, cmd := m.updateTaskDue(taskDueMsg{"b"}); cmd != nil {
+		cmd()
+	}
+	if len(p.done) != 0 {
+		t.Errorf("provider told %q done after undoing", p.done)
+	}
+}
+
+func TestCompleteTaskDue(t *testing.T) {
+	p := &fakeTasks{}
+	m := taskModel(p, "a", "b")
+
+	next, _ := m.completeTask("a")
+	next, cmd := next.(model).updateTaskDue(taskDueMsg{"a"})
+	m = next.(model)
+	if cmd == nil {
+		t.Fatal("updateTaskDue() returned no command")
+	}
+	if msg := cmd().(jobDoneMsg); msg.err != nil {
+		t.Errorf("completing = %v", msg.err)
+	}
+	if !slices.Equal(p.done, []string{"a"}) {
+		t.Errorf("provider told %q done, want a", p.done)
+	}
+	if len(m.undo) != 0 {
+		t.Errorf("%d undo entries left for a task already done", len(m.undo))
+	}
+
+	// Once the provider was told, its list is shown as is.
+	next, _ = m.updateTaskItems(tasksMsg{names: []string{"b"}})
+	if got := taskNames(next.(model).tasks); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("tasks after reloading = %q", got)
+	}
+}
+
+func TestCompleteTaskHiddenOnReload(t *testing.T) {
+	m := taskModel(&fakeTasks{}, "a", "b")
+
+	next, _ := m.completeTask("a")
+	next, _ = next.(model).updateTaskItems(tasksMsg{names: []string{"a", "b"}})
+	if got := taskNames(next.(model).tasks); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("tasks reloaded before the provider was told = %q", got)
+	}
+}
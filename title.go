@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// windowTitle is the countdown shown in the terminal's title, time first
+// so it still fits in a narrow tab.
+func (m model) windowTitle() string {
+	title := formatDuration(m.timer.Timeout, formatClock) + " " + m.phaseLabel()
+	if m.caps.Unicode {
+		title = barIcons[m.phase()] + " " + title
+	}
+	return title
+}
+
+// titleOutput is where title sequences go, or nil when the title is left
+// alone.
+func (m model) titleOutput() io.Writer {
+	if !m.cfg.TerminalTitle || !m.caps.OSC {
+		return nil
+	}
+	return os.Stdout
+}
+
+// updateTitle sets the terminal title to the countdown when it changed.
+// The renderer writes it between frames, so it can't garble them.
+func (m model) updateTitle() (model, tea.Cmd) {
+	title := m.windowTitle()
+	if m.titleOutput() == nil || title == m.title {
+		return m, nil
+	}
+
+	m.title = title
+	return m, tea.SetWindowTitle(title)
+}
+
+// saveTitle pushes the terminal's title on its title stack, so
+// restoreTitle can put it back on exit. It writes to the terminal
+// directly, as it runs before the program starts.
+func saveTitle(w io.Writer) {
+	if w != nil {
+		io.WriteString(w, "\x1b[22;0t")
+	}
+}
+
+// restoreTitle clears the countdown from the title and pops the one saved
+// by saveTitle, in terminals that keep a title stack. Like saveTitle, it
+// writes directly, once the program has returned.
+func restoreTitle(w io.Writer) {
+	if w != nil {
+		io.WriteString(w, "\x1b]0;\a\x1b[23;0t")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toastDuration is how long a toast stays visible.
+const toastDuration = time.Second * 3
+
+// toastExpiredMsg hides the toast it was scheduled for.
+type toastExpiredMsg struct {
+	id int
+}
+
+// showToast shows a short message under the timer for a few seconds.
+func (m model) showToast(text string) (model, tea.Cmd) {
+	m.toastID++
+	m.toast = text
+
+	id := m.toastID
+	return m, tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id}
+	})
+}
+
+func (m model) toastView() string {
+	if m.toast == "" {
+		return ""
+	}
+	return "\n" + lipgloss.NewStyle().Reverse(true).Padding(0, 1).Render(m.toast)
+}
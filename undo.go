@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// undoDepth is how many actions can be undone.
+const undoDepth = 10
+
+// undoEntry reverts a destructive action.
+type undoEntry struct {
+	desc   string
+	revert func(model) (model, tea.Cmd)
+}
+
+func (m model) pushUndo(desc string, revert func(model) (model, tea.Cmd)) model {
+	m.undo = append(slices.Clip(m.undo), undoEntry{desc, revert})
+	if len(m.undo) > undoDepth {
+		m.undo = m.undo[1:]
+	}
+	return m
+}
+
+// dropUndo forgets the actions described as desc, once they can no longer
+// be reverted.
+func (m model) dropUndo(desc string) model {
+	m.undo = slices.DeleteFunc(slices.Clone(m.undo), func(e undoEntry) bool { return e.desc == desc })
+	return m
+}
+
+// undoLast reverts the most recent destructive action.
+func (m model) undoLast() (tea.Model, tea.Cmd) {
+	if len(m.undo) == 0 {
+		return m.showToast("Nothing to undo")
+	}
+
+	entry := m.undo[len(m.undo)-1]
+	m.undo = slices.Clip(m.undo[:len(m.undo)-1])
+
+	m, cmd := entry.revert(m)
+	m, toastCmd := m.showToast("Undid " + entry.desc)
+	return m, tea.Batch(cmd, toastCmd)
+}
+
+// undoableSession remembers the running session before an action that
+// ends it, like a reset or a skip. Undoing returns to the session where it
+// was left and drops its aborted record from the history.
+func (m model) undoableSession(desc string) model {
+	if m.sessionStart.IsZero() {
+		return m
+	}
+	saved := m
+
+	return m.pushUndo(desc, func(m model) (model, tea.Cmd) {
+		var historyCmd tea.Cmd
+		if m.history != nil {
+			store := m.history
+			historyCmd = m.jobs.Run("history", time.Second*5, func(ctx context.Context) error {
+				return store.Delete(ctx, saved.sessionStart)
+			})
+		}
+
+		m.pomo = saved.pomo
+		m.segment = saved.segment
+		m.intention = saved.intention
+		m.pausedBy = saved.pausedBy
+		m.sessionStart = saved.sessionStart
+		m.sessionLength = saved.sessionLength
+		m.askingIntention = false
+		m.intentionInput.Blur()
+
+		// A new timer, so ticks still pending for the replaced one are
+		// ignored.
+		m.timer = m.newTimer(saved.timer.Timeout)
+		timerCmd := m.timer.Stop()
+		if saved.timer.Running() {
+			timerCmd = m.timer.Start()
+		}
+
+		return m, tea.Batch(m.progress.SetPercent(m.elapsed()), historyCmd, timerCmd)
+	})
+}